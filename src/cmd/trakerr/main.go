@@ -0,0 +1,148 @@
+// Command trakerr sends events to Trakerr from the command line, eg. from cron jobs and deploy scripts.
+//
+// Usage:
+//
+//	trakerr <command> [flags]
+//
+// The API key, the URL of the Trakerr API, the app version and the deployment stage are read from the -api-key,
+// -url, -app-version and -stage flags, or else from the TRAKERR_API_KEY, TRAKERR_URL, TRAKERR_APP_VERSION and
+// TRAKERR_DEPLOYMENT_STAGE environment variables.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+)
+
+// command is a subcommand of trakerr.
+type command struct {
+	summary string
+	run     func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"mock-server": {"serve a fake Trakerr API for development and CI", runMockServer},
+	"release":     {"send a release marker for a deploy", runRelease},
+	"send":        {"send an event", runSend},
+	"tail":        {"follow log files and send the matching lines as events", runTail},
+	"test":        {"send a test error to check the API key", runTest},
+	"validate":    {"check AppEvent JSON files against the model", runValidate},
+}
+
+// errUsage is returned by commands for invalid flags or arguments, after the flag set printed the usage.
+var errUsage = errors.New("usage error")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command in args and returns the exit code: 0 on success, 1 when the command failed and 2 for a
+// usage error.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "trakerr: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err := cmd.run(args[1:], stdin, stdout, stderr); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "trakerr %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: trakerr <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run trakerr <command> -h for the flags of a command.")
+}
+
+// newFlagSet returns the flag set of the named command, printing its errors and usage to stderr.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("trakerr "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// parseFlags parses args with flags; the flag set already printed the error and the usage on failure.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// clientConfig is the configuration of the TrakerrClient of a command.
+type clientConfig struct {
+	apiKey          string
+	url             string
+	appVersion      string
+	deploymentStage string
+}
+
+// register adds the client flags to flags, with their defaults from the environment.
+func (config *clientConfig) register(flags *flag.FlagSet) {
+	flags.StringVar(&config.apiKey, "api-key", os.Getenv("TRAKERR_API_KEY"), "Trakerr API key (default $TRAKERR_API_KEY)")
+	flags.StringVar(&config.url, "url", os.Getenv("TRAKERR_URL"), "URL of the Trakerr API (default $TRAKERR_URL, or else https://www.trakerr.io/api/v1)")
+}
+
+// registerContext adds the app version and deployment stage flags to flags, with their defaults from the environment.
+func (config *clientConfig) registerContext(flags *flag.FlagSet) {
+	flags.StringVar(&config.appVersion, "app-version", envOr("TRAKERR_APP_VERSION", "1.0"), "app version of the events (default $TRAKERR_APP_VERSION, or else 1.0)")
+	flags.StringVar(&config.deploymentStage, "stage", os.Getenv("TRAKERR_DEPLOYMENT_STAGE"), "deployment stage of the events (default $TRAKERR_DEPLOYMENT_STAGE, or else development)")
+}
+
+// newClient returns the client configured by the flags and the environment.
+func (config *clientConfig) newClient() (*trakerr.TrakerrClient, error) {
+	if config.apiKey == "" {
+		return nil, errors.New("no API key, set -api-key or TRAKERR_API_KEY")
+	}
+	client := trakerr.NewTrakerrClient(config.apiKey, config.appVersion, config.deploymentStage)
+	if config.url != "" {
+		client.SetBaseURL(config.url)
+	}
+	return client, nil
+}
+
+// envOr returns the environment variable key, or fallback when it is empty.
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// checkResponse returns err, or an error for a response the API rejected.
+func checkResponse(response *trakerr.APIResponse, err error) error {
+	if err != nil {
+		return err
+	}
+	if response != nil && response.Response != nil && response.StatusCode > 399 {
+		return fmt.Errorf("the Trakerr API returned %s", response.Status)
+	}
+	return nil
+}
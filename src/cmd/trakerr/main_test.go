@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+	"github.com/trakerr-io/trakerr-go/src/trakerr/trakerrtest"
+)
+
+func newTestAPI(t *testing.T) (*trakerrtest.Server, string) {
+	server := trakerrtest.NewServer()
+	t.Cleanup(server.Close)
+	return server, server.URL
+}
+
+// runCommand runs trakerr with args and returns the exit code, stdout and stderr.
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunUsage(t *testing.T) {
+	if code, _, stderr := runCommand(""); code != 2 || !strings.Contains(stderr, "release") {
+		t.Errorf("no command: exit %d, %q", code, stderr)
+	}
+	if code, _, stderr := runCommand("", "deploy"); code != 2 || !strings.Contains(stderr, `unknown command "deploy"`) {
+		t.Errorf("unknown command: exit %d, %q", code, stderr)
+	}
+	if code, _, _ := runCommand("", "release", "-bogus"); code != 2 {
+		t.Errorf("unknown flag: exit %d", code)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	api, url := newTestAPI(t)
+	t.Setenv("TRAKERR_API_KEY", "env-key")
+	t.Setenv("TRAKERR_URL", url)
+
+	code, stdout, stderr := runCommand("", "release", "-version", "1.4.2", "-revision", "0123abcd", "-deployer", "ci", "-changelog", "https://example.com/1.4.2")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if stdout != "sent release 1.4.2 to production\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	events := api.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	marker := events[0]
+	if marker.ApiKey != "env-key" || marker.EventType != trakerr.ReleaseEventType || marker.ContextAppVersion != "1.4.2" || marker.DeploymentStage != "production" {
+		t.Errorf("unexpected marker %q %q %q %q", marker.ApiKey, marker.EventType, marker.ContextAppVersion, marker.DeploymentStage)
+	}
+	if !hasTag(marker, "release.revision:0123abcd") || !hasTag(marker, "release.deployer:ci") {
+		t.Errorf("unexpected tags %v", marker.ContextTags)
+	}
+
+	if code, _, _ := runCommand("", "release", "-stage", "staging"); code != 2 {
+		t.Errorf("missing version: exit %d", code)
+	}
+	api.SetResponse(trakerrtest.Response{Status: http.StatusUnauthorized})
+	if code, _, stderr := runCommand("", "release", "-api-key", "bad-key", "-version", "1.4.3"); code != 1 || !strings.Contains(stderr, "401") {
+		t.Errorf("rejected key: exit %d, %q", code, stderr)
+	}
+	t.Setenv("TRAKERR_API_KEY", "")
+	if code, _, stderr := runCommand("", "release", "-version", "1.4.3"); code != 1 || !strings.Contains(stderr, "no API key") {
+		t.Errorf("no API key: exit %d, %q", code, stderr)
+	}
+}
+
+func hasTag(event trakerr.AppEvent, tag string) bool {
+	for _, t := range event.ContextTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
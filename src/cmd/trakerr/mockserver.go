@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr/trakerrtest"
+)
+
+// runMockServer serves a fake events API until it is interrupted, for development and CI.
+func runMockServer(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("mock-server", stderr)
+	addr := flags.String("addr", "localhost:8089", "address to listen on")
+	var response trakerrtest.Response
+	flags.IntVar(&response.Status, "status", 0, "answer every event with this status, eg. 429 or 503, instead of validating and recording it")
+	flags.DurationVar(&response.Delay, "latency", 0, "time to wait before answering")
+	flags.DurationVar(&response.RetryAfter, "retry-after", 0, "Retry-After header of the responses, eg. with -status 429")
+	quiet := flags.Bool("quiet", false, "don't print a line per event")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: trakerr mock-server [flags]")
+		flags.PrintDefaults()
+		return errUsage
+	}
+
+	handler := trakerrtest.NewHandler()
+	handler.SetResponse(response)
+	if !*quiet {
+		handler.SetLog(stdout)
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "mock Trakerr API listening on http://%s, set TRAKERR_URL or call SetBaseURL with it\n", listener.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return serveMock(ctx, listener, handler)
+}
+
+// serveMock serves handler on listener until ctx is done, then gives the requests in flight 5s to finish.
+func serveMock(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	server := &http.Server{Handler: handler}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); errors.Is(err, context.DeadlineExceeded) {
+		server.Close()
+	} else if err != nil {
+		return err
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr/trakerrtest"
+)
+
+func TestMockServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := trakerrtest.NewHandler()
+	var log bytes.Buffer
+	handler.SetLog(&log)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveMock(ctx, listener, handler)
+	}()
+
+	url := "http://" + listener.Addr().String()
+	if code, _, stderr := runCommand("", "send", "-api-key", "key", "-url", url, "-type", "Cron", "nightly job failed"); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	response, err := http.Post(url+"/events", "application/json", strings.NewReader(`{"apiKey": "key"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	handler.SetResponse(trakerrtest.Response{Status: http.StatusTooManyRequests})
+	if code, _, stderr := runCommand("", "send", "-api-key", "key", "-url", url, "rate limited"); code != 1 || !strings.Contains(stderr, "429") {
+		t.Errorf("expected the 429 to fail the command: exit %d, %q", code, stderr)
+	}
+
+	http.DefaultClient.CloseIdleConnections()
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 3 || lines[0] != "accepted: error Cron: nightly job failed" || !strings.HasPrefix(lines[1], "rejected: trakerr: invalid AppEvent: classification is required") || lines[2] != "failed: answered 429 Too Many Requests" {
+		t.Errorf("unexpected log:\n%s", log.String())
+	}
+	if len(handler.Events()) != 1 {
+		t.Errorf("expected 1 event, got %d", len(handler.Events()))
+	}
+}
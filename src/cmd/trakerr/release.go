@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+)
+
+// runRelease sends a release marker, by default for the revision checked out in the working directory.
+func runRelease(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("release", stderr)
+	var config clientConfig
+	config.register(flags)
+	var release trakerr.Release
+	flags.StringVar(&release.Version, "version", "", "app version that was deployed (required)")
+	flags.StringVar(&release.DeploymentStage, "stage", "production", "deployment stage it was deployed to")
+	flags.StringVar(&release.Revision, "revision", "", "VCS revision it was built from (default the output of git rev-parse HEAD)")
+	flags.StringVar(&release.ChangelogURL, "changelog", "", "URL of the changes in the release")
+	flags.StringVar(&release.Deployer, "deployer", "", "who or what deployed it")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if release.Version == "" || flags.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: trakerr release -version <version> [flags]")
+		flags.PrintDefaults()
+		return errUsage
+	}
+	if release.Revision == "" {
+		release.Revision = gitRevision()
+	}
+	release.Time = time.Now()
+
+	config.appVersion, config.deploymentStage = release.Version, release.DeploymentStage
+	client, err := config.newClient()
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(client.SendRelease(release)); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "sent release %s to %s\n", release.Version, release.DeploymentStage)
+	return nil
+}
+
+// gitRevision returns the commit checked out in the working directory, or "" outside of a git repository.
+func gitRevision() string {
+	output, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+)
+
+// slotValues is a repeatable flag of custom data slots, each given as <slot>=<value> with a slot of 1 to 10.
+type slotValues map[int]string
+
+func (values slotValues) String() string {
+	return ""
+}
+
+func (values slotValues) Set(value string) error {
+	slot, data, ok := strings.Cut(value, "=")
+	n, err := strconv.Atoi(slot)
+	if !ok || err != nil || n < 1 || n > 10 {
+		return errors.New("want <slot>=<value> with a slot of 1 to 10")
+	}
+	values[n] = data
+	return nil
+}
+
+// tagValues is a repeatable flag of tags, each given as <key>=<value>.
+type tagValues map[string]string
+
+func (values tagValues) String() string {
+	return ""
+}
+
+func (values tagValues) Set(value string) error {
+	key, tag, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return errors.New("want <key>=<value>")
+	}
+	values[key] = tag
+	return nil
+}
+
+// runSend sends an event built from the flags, on top of the AppEvent JSON read from stdin with -json.
+// The message is the -message flag or the arguments.
+func runSend(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("send", stderr)
+	var config clientConfig
+	config.register(flags)
+	config.registerContext(flags)
+	var event trakerr.AppEvent
+	readJSON := flags.Bool("json", false, "read the event as AppEvent JSON from stdin; the flags override its fields")
+	flags.StringVar(&event.LogLevel, "level", "", "log level: debug, info, warning, error or fatal (default error)")
+	flags.StringVar(&event.Classification, "classification", "", "classification of the event (default issue)")
+	flags.StringVar(&event.EventType, "type", "", "type of the event (default unknown)")
+	flags.StringVar(&event.EventMessage, "message", "", "message of the event, or else the arguments")
+	flags.StringVar(&event.EventUser, "user", "", "user the event is about")
+	flags.StringVar(&event.EventSession, "session", "", "session the event belongs to")
+	flags.StringVar(&event.ContextCrossAppCorrelationId, "correlation-id", "", "cross application correlation ID")
+	stringSlots, doubleSlots, tags := slotValues{}, slotValues{}, tagValues{}
+	flags.Var(stringSlots, "string", "string custom property `slot=value`, slot 1 to 10 (repeatable)")
+	flags.Var(doubleSlots, "double", "double custom property `slot=value`, slot 1 to 10 (repeatable)")
+	flags.Var(tags, "tag", "tag `key=value` (repeatable)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if event.EventMessage == "" {
+		event.EventMessage = strings.Join(flags.Args(), " ")
+	}
+
+	appEvent := &trakerr.AppEvent{}
+	if *readJSON {
+		var err error
+		if appEvent, err = trakerr.DecodeAppEvent(stdin); err != nil {
+			return err
+		}
+	}
+	overrideFields(appEvent, &event)
+	for slot, value := range stringSlots {
+		appEvent.CustomProperties.SetString(slot, value)
+	}
+	for slot, value := range doubleSlots {
+		double, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("-double %d=%s is not a number", slot, value)
+		}
+		appEvent.CustomProperties.SetDouble(slot, double)
+	}
+	if appEvent.EventMessage == "" {
+		fmt.Fprintln(stderr, "usage: trakerr send [flags] <message>")
+		flags.PrintDefaults()
+		return errUsage
+	}
+
+	client, err := config.newClient()
+	if err != nil {
+		return err
+	}
+	for key, value := range tags {
+		client.SetContextTag(key, value)
+	}
+	fillEventDefaults(client, appEvent)
+	if err := appEvent.Validate(); err != nil {
+		return err
+	}
+	return checkResponse(client.SendEvent(appEvent))
+}
+
+// runTest sends a sample error with a stack trace, to check the API key and the connection to the API.
+func runTest(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("test", stderr)
+	var config clientConfig
+	config.register(flags)
+	config.registerContext(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	client, err := config.newClient()
+	if err != nil {
+		return err
+	}
+	appEvent := client.CreateAppEventFromError("error", "", errors.New("this is a test error sent by trakerr test"))
+	if err := checkResponse(client.SendEvent(appEvent)); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "sent a test error, the API key works")
+	return nil
+}
+
+// overrideFields copies the fields set by the flags in flagged over appEvent.
+func overrideFields(appEvent *trakerr.AppEvent, flagged *trakerr.AppEvent) {
+	for _, field := range []struct {
+		target *string
+		value  string
+	}{
+		{&appEvent.LogLevel, flagged.LogLevel},
+		{&appEvent.Classification, flagged.Classification},
+		{&appEvent.EventType, flagged.EventType},
+		{&appEvent.EventMessage, flagged.EventMessage},
+		{&appEvent.EventUser, flagged.EventUser},
+		{&appEvent.EventSession, flagged.EventSession},
+		{&appEvent.ContextCrossAppCorrelationId, flagged.ContextCrossAppCorrelationId},
+	} {
+		if field.value != "" {
+			*field.target = field.value
+		}
+	}
+}
+
+// fillEventDefaults fills in the fields of appEvent that are still empty like NewAppEvent does. Unlike
+// NewAppEvent it keeps an unknown log level, so Validate rejects it instead of sending it as an error.
+func fillEventDefaults(client *trakerr.TrakerrClient, appEvent *trakerr.AppEvent) {
+	appEvent.LogLevel = strings.ToLower(appEvent.LogLevel)
+	defaults := client.NewAppEvent(appEvent.LogLevel, appEvent.Classification, appEvent.EventType, appEvent.EventMessage)
+	if appEvent.LogLevel == "" {
+		appEvent.LogLevel = defaults.LogLevel
+	}
+	appEvent.Classification, appEvent.EventType = defaults.Classification, defaults.EventType
+	client.FillDefaults(appEvent)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	api, url := newTestAPI(t)
+	t.Setenv("TRAKERR_API_KEY", "env-key")
+	t.Setenv("TRAKERR_URL", url)
+	t.Setenv("TRAKERR_APP_VERSION", "2.0")
+
+	code, _, stderr := runCommand("", "send", "-level", "Warning", "-type", "BackupFailed", "-user", "cron", "-session", "nightly",
+		"-string", "1=db01", "-double", "2=17.5", "-tag", "job=backup", "disk", "full")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	json := `{"logLevel": "info", "eventType": "Cron", "eventMessage": "from stdin", "customProperties": {"stringData": {"customData3": "kept"}}}`
+	if code, _, stderr := runCommand(json, "send", "-json", "-stage", "staging", "-level", "error"); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+
+	events := api.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	flagged := events[0]
+	if flagged.LogLevel != "warning" || flagged.Classification != "issue" || flagged.EventType != "BackupFailed" || flagged.EventMessage != "disk full" {
+		t.Errorf("unexpected event %q %q %q %q", flagged.LogLevel, flagged.Classification, flagged.EventType, flagged.EventMessage)
+	}
+	if flagged.ApiKey != "env-key" || flagged.ContextAppVersion != "2.0" || flagged.EventUser != "cron" || flagged.EventSession != "nightly" {
+		t.Errorf("unexpected context %q %q %q %q", flagged.ApiKey, flagged.ContextAppVersion, flagged.EventUser, flagged.EventSession)
+	}
+	if flagged.CustomProperties.StringData.CustomData1 != "db01" || flagged.CustomProperties.DoubleData.CustomData2 != 17.5 || !hasTag(flagged, "job:backup") {
+		t.Errorf("unexpected custom data %+v %v", flagged.CustomProperties, flagged.ContextTags)
+	}
+	fromStdin := events[1]
+	if fromStdin.LogLevel != "error" || fromStdin.EventType != "Cron" || fromStdin.EventMessage != "from stdin" || fromStdin.DeploymentStage != "staging" || fromStdin.CustomProperties.StringData.CustomData3 != "kept" {
+		t.Errorf("unexpected event from stdin %+v", fromStdin)
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	api, url := newTestAPI(t)
+	t.Setenv("TRAKERR_API_KEY", "env-key")
+	t.Setenv("TRAKERR_URL", url)
+
+	for _, test := range []struct {
+		stdin  string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{"", []string{"send"}, 2, "usage: trakerr send"},
+		{"", []string{"send", "-string", "11=x", "message"}, 2, "slot of 1 to 10"},
+		{"", []string{"send", "-tag", "novalue", "message"}, 2, "want <key>=<value>"},
+		{"", []string{"send", "-double", "1=many", "message"}, 1, "not a number"},
+		{"", []string{"send", "-level", "verbose", "message"}, 1, `logLevel "verbose"`},
+		{`{"eventMesage": "typo"}`, []string{"send", "-json"}, 1, "eventMesage"},
+	} {
+		code, _, stderr := runCommand(test.stdin, test.args...)
+		if code != test.code || !strings.Contains(stderr, test.stderr) {
+			t.Errorf("%v: exit %d, %q", test.args, code, stderr)
+		}
+	}
+	if len(api.Events()) != 0 {
+		t.Errorf("invalid events were sent: %+v", api.Events())
+	}
+}
+
+func TestTest(t *testing.T) {
+	api, url := newTestAPI(t)
+	code, stdout, stderr := runCommand("", "test", "-api-key", "flag-key", "-url", url)
+	if code != 0 || !strings.Contains(stdout, "the API key works") {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	events := api.Events()
+	if len(events) != 1 || events[0].ApiKey != "flag-key" || events[0].LogLevel != "error" || len(events[0].EventStacktrace) == 0 {
+		t.Errorf("unexpected test events %+v", events)
+	}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+)
+
+// logFileTagKey is the tag key of the file a tailed line was read from.
+const logFileTagKey = "log.file"
+
+// fingerprintSize is the maximum number of bytes at the start of a file that identify it in the state file, so
+// the offset of a file that was rotated or rewritten since is not reused.
+const fingerprintSize = 256
+
+// patternValues is a repeatable flag of regular expressions.
+type patternValues []*regexp.Regexp
+
+func (values *patternValues) String() string {
+	return ""
+}
+
+func (values *patternValues) Set(value string) error {
+	pattern, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	*values = append(*values, pattern)
+	return nil
+}
+
+// runTail follows log files and sends the lines that match a pattern as events, until it is interrupted.
+func runTail(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("tail", stderr)
+	var config clientConfig
+	config.register(flags)
+	config.registerContext(flags)
+	tailer := &tailer{stderr: stderr}
+	flags.Var(&tailer.patterns, "pattern", "regular expression of the lines to send (repeatable); the named captures level, classification, type, message, user, session, correlation, string1 to string10 and double1 to double10 fill the event")
+	multiline := flags.String("multiline", `^(\s|Caused by:)`, "regular expression of the continuation lines, eg. of a stack trace, added to the event of the line before; empty turns grouping off")
+	flags.IntVar(&tailer.maxLines, "max-lines", 200, "maximum number of lines in an event")
+	flags.StringVar(&tailer.level, "level", "error", "log level of the events without a level capture")
+	flags.StringVar(&tailer.classification, "classification", "", "classification of the events without a classification capture (default issue)")
+	flags.StringVar(&tailer.eventType, "type", "LogLine", "type of the events without a type capture")
+	flags.StringVar(&tailer.statePath, "state", "", "file the read offsets are saved in, to resume after a restart")
+	flags.BoolVar(&tailer.fromStart, "from-start", false, "read the files from the start instead of the end when there is no saved offset")
+	poll := flags.Duration("poll", time.Second, "how often the files are checked for new lines")
+	once := flags.Bool("once", false, "read the files once and exit instead of following them")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || len(tailer.patterns) == 0 || *poll <= 0 {
+		fmt.Fprintln(stderr, "usage: trakerr tail -pattern <regexp> [flags] <file>...")
+		flags.PrintDefaults()
+		return errUsage
+	}
+	if *multiline != "" {
+		continuation, err := regexp.Compile(*multiline)
+		if err != nil {
+			return fmt.Errorf("-multiline: %v", err)
+		}
+		tailer.continuation = continuation
+	}
+
+	client, err := config.newClient()
+	if err != nil {
+		return err
+	}
+	tailer.client = client
+	if err := tailer.open(flags.Args()); err != nil {
+		return err
+	}
+	defer tailer.close()
+	if *once {
+		return tailer.poll(true)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return tailer.run(ctx, *poll)
+}
+
+// tailer follows log files and sends the lines matching its patterns as events.
+type tailer struct {
+	client         *trakerr.TrakerrClient
+	patterns       patternValues
+	continuation   *regexp.Regexp
+	maxLines       int
+	level          string
+	classification string
+	eventType      string
+	statePath      string
+	fromStart      bool
+	stderr         io.Writer
+
+	files []*tailFile
+	state tailState
+}
+
+// tailState is the content of the state file: the read offset of every file.
+type tailState struct {
+	Files map[string]fileState `json:"files"`
+}
+
+type fileState struct {
+	Offset          int64  `json:"offset"`
+	Fingerprint     string `json:"fingerprint"`
+	FingerprintSize int64  `json:"fingerprintSize"`
+}
+
+// tailFile is a followed log file.
+type tailFile struct {
+	path string
+	ctx  context.Context
+
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte // the start of a line still being written
+
+	pending       *trakerr.AppEvent
+	pendingLines  int
+	pendingOffset int64 // where the first line of the pending event starts
+}
+
+// open loads the state file and opens the files at path, resuming at their saved offset.
+func (tailer *tailer) open(paths []string) error {
+	tailer.state.Files = make(map[string]fileState)
+	if tailer.statePath != "" {
+		data, err := os.ReadFile(tailer.statePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &tailer.state); err != nil {
+				return fmt.Errorf("cannot read the state file %s: %v", tailer.statePath, err)
+			}
+		}
+		if tailer.state.Files == nil {
+			tailer.state.Files = make(map[string]fileState)
+		}
+	}
+	for _, path := range paths {
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		ctx, scope := trakerr.WithScope(context.Background())
+		scope.SetTag(logFileTagKey, absolute)
+		file := &tailFile{path: absolute, ctx: ctx}
+		tailer.files = append(tailer.files, file)
+		saved, ok := tailer.state.Files[absolute]
+		if err := file.open(saved, ok, tailer.fromStart); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tailer *tailer) close() {
+	for _, file := range tailer.files {
+		file.close()
+	}
+}
+
+// run polls the files every interval until ctx is done, then sends the events still being grouped.
+func (tailer *tailer) run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := tailer.poll(false); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return tailer.poll(true)
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads the new lines of every file, follows rotations and truncations and saves the offsets. The event of a
+// file that had no new lines is sent, as its stack trace is complete; final sends every pending event.
+func (tailer *tailer) poll(final bool) error {
+	for _, file := range tailer.files {
+		read, err := tailer.pollFile(file)
+		if err != nil {
+			fmt.Fprintf(tailer.stderr, "trakerr tail: %s: %v\n", file.path, err)
+		}
+		if !read || final {
+			tailer.flush(file)
+		}
+	}
+	return tailer.saveState()
+}
+
+// pollFile reads the new lines of file, then reopens it when it was rotated and rewinds it when it was truncated.
+// It reports whether there were new lines.
+func (tailer *tailer) pollFile(file *tailFile) (bool, error) {
+	if file.file == nil {
+		if err := file.open(fileState{}, false, true); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	read, err := tailer.readLines(file)
+	if err != nil {
+		return read, err
+	}
+
+	info, err := os.Stat(file.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// rotated away and not recreated yet: keep the old file until the new one appears
+		return read, nil
+	case err != nil:
+		return read, err
+	case !os.SameFile(info, file.info):
+		// the old file was read to its end above
+		tailer.flush(file)
+		file.close()
+		if err := file.open(fileState{}, false, true); err != nil {
+			return read, err
+		}
+		more, err := tailer.readLines(file)
+		return read || more, err
+	case info.Size() < file.offset:
+		tailer.flush(file)
+		if _, err := file.file.Seek(0, io.SeekStart); err != nil {
+			return read, err
+		}
+		file.offset, file.partial = 0, nil
+		more, err := tailer.readLines(file)
+		return read || more, err
+	}
+	return read, nil
+}
+
+// readLines reads file to its end and handles every complete line.
+func (tailer *tailer) readLines(file *tailFile) (bool, error) {
+	reader := bufio.NewReader(file.file)
+	read := false
+	for {
+		chunk, err := reader.ReadBytes('\n')
+		file.offset += int64(len(chunk))
+		if err == io.EOF {
+			file.partial = append(file.partial, chunk...)
+			break
+		}
+		if err != nil {
+			return read, err
+		}
+		line := append(file.partial, chunk...)
+		file.partial = nil
+		tailer.handleLine(file, file.offset-int64(len(line)), strings.TrimRight(string(line), "\r\n"))
+		read = true
+	}
+	return read, nil
+}
+
+// handleLine adds a continuation line to the pending event of file, or else starts the event of the first
+// pattern the line matches. Other lines are skipped. start is the offset of the line in the file.
+func (tailer *tailer) handleLine(file *tailFile, start int64, line string) {
+	if file.pending != nil && tailer.continuation != nil && tailer.continuation.MatchString(line) {
+		if file.pendingLines < tailer.maxLines {
+			file.pending.EventMessage += "\n" + line
+			file.pendingLines++
+		}
+		return
+	}
+	tailer.flush(file)
+	for _, pattern := range tailer.patterns {
+		if match := pattern.FindStringSubmatch(line); match != nil {
+			file.pending = tailer.newEvent(pattern, match, line)
+			file.pendingLines = 1
+			file.pendingOffset = start
+			return
+		}
+	}
+}
+
+// newEvent returns the event of a line matching pattern, filled from its named captures.
+func (tailer *tailer) newEvent(pattern *regexp.Regexp, match []string, line string) *trakerr.AppEvent {
+	level, classification, eventType, message := tailer.level, tailer.classification, tailer.eventType, line
+	var fields trakerr.AppEvent
+	for i, name := range pattern.SubexpNames() {
+		value := match[i]
+		if name == "" || value == "" {
+			continue
+		}
+		switch name {
+		case "level":
+			level = value
+		case "classification":
+			classification = value
+		case "type":
+			eventType = value
+		case "message":
+			message = value
+		case "user":
+			fields.EventUser = value
+		case "session":
+			fields.EventSession = value
+		case "correlation":
+			fields.ContextCrossAppCorrelationId = value
+		default:
+			setSlot(&fields.CustomProperties, name, value)
+		}
+	}
+	appEvent := tailer.client.NewAppEvent(level, classification, eventType, message)
+	appEvent.EventUser, appEvent.EventSession = fields.EventUser, fields.EventSession
+	appEvent.ContextCrossAppCorrelationId = fields.ContextCrossAppCorrelationId
+	appEvent.CustomProperties = fields.CustomProperties
+	return appEvent
+}
+
+// setSlot sets the custom data slot named by a capture, string1 to string10 or double1 to double10. Other names
+// and doubles that don't parse are ignored.
+func setSlot(customData *trakerr.CustomData, name string, value string) {
+	if slot, ok := slotNumber(name, "string"); ok {
+		customData.SetString(slot, value)
+	} else if slot, ok := slotNumber(name, "double"); ok {
+		if double, err := strconv.ParseFloat(value, 64); err == nil {
+			customData.SetDouble(slot, double)
+		}
+	}
+}
+
+func slotNumber(name string, prefix string) (int, bool) {
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false
+	}
+	slot, err := strconv.Atoi(name[len(prefix):])
+	return slot, err == nil && slot >= 1 && slot <= 10
+}
+
+// flush sends the pending event of file.
+func (tailer *tailer) flush(file *tailFile) {
+	if file.pending == nil {
+		return
+	}
+	appEvent := file.pending
+	file.pending, file.pendingLines = nil, 0
+	if err := checkResponse(tailer.client.SendEventContext(file.ctx, appEvent)); err != nil && !errors.Is(err, trakerr.ErrEventDropped) {
+		fmt.Fprintf(tailer.stderr, "trakerr tail: %s: %v\n", file.path, err)
+	}
+}
+
+// saveState writes the offsets of the files to the state file, through a temporary file so a crash can't
+// leave it half written.
+func (tailer *tailer) saveState() error {
+	if tailer.statePath == "" {
+		return nil
+	}
+	for _, file := range tailer.files {
+		if file.file == nil {
+			continue
+		}
+		// the partial line and the lines of the pending event are read again after a restart
+		offset := file.offset - int64(len(file.partial))
+		if file.pending != nil {
+			offset = file.pendingOffset
+		}
+		size := offset
+		if size > fingerprintSize {
+			size = fingerprintSize
+		}
+		fingerprint, err := fingerprintOf(file.file, size)
+		if err != nil {
+			return err
+		}
+		tailer.state.Files[file.path] = fileState{Offset: offset, Fingerprint: fingerprint, FingerprintSize: size}
+	}
+	data, err := json.MarshalIndent(tailer.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := tailer.statePath + ".tmp"
+	if err := os.WriteFile(temp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temp, tailer.statePath)
+}
+
+// open opens the file at its path. With a saved state it resumes at the saved offset when the file still has the
+// saved fingerprint and reads it from the start otherwise; without one it starts at the start or the end.
+func (file *tailFile) open(saved fileState, resume bool, fromStart bool) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	offset := info.Size()
+	if resume {
+		// a file with another fingerprint replaced the one the offset was saved for
+		offset = 0
+		if saved.Offset <= info.Size() && saved.FingerprintSize <= saved.Offset {
+			fingerprint, err := fingerprintOf(f, saved.FingerprintSize)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			if fingerprint == saved.Fingerprint {
+				offset = saved.Offset
+			}
+		}
+	} else if fromStart {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	file.file, file.info, file.offset, file.partial = f, info, offset, nil
+	return nil
+}
+
+func (file *tailFile) close() {
+	if file.file != nil {
+		file.file.Close()
+		file.file = nil
+	}
+}
+
+// fingerprintOf returns the SHA-256 of the first size bytes of f.
+func fingerprintOf(f *os.File, size int64) (string, error) {
+	head := make([]byte, size)
+	if _, err := f.ReadAt(head, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	sum := sha256.Sum256(head)
+	return hex.EncodeToString(sum[:]), nil
+}
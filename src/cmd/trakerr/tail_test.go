@@ -0,0 +1,176 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+	"github.com/trakerr-io/trakerr-go/src/trakerr/trakerrtest"
+)
+
+const testLogPattern = `^\S+ \S+ (?P<level>ERROR|WARN) \[(?P<type>\w+)\] user=(?P<user>\w+) (?:took=(?P<double1>[\d.]+) )?(?P<message>.*)$`
+
+func appendFile(t *testing.T, path string, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	api, url := newTestAPI(t)
+	t.Setenv("TRAKERR_API_KEY", "env-key")
+	t.Setenv("TRAKERR_URL", url)
+	dir := t.TempDir()
+	log := filepath.Join(dir, "app.log")
+	state := filepath.Join(dir, "state.json")
+	appendFile(t, log, `2024-05-01 12:00:00 INFO [Start] user=root started
+2024-05-01 12:00:01 ERROR [PaymentError] user=alice charge failed
+	at billing.Charge(billing.go:42)
+	at main.main(main.go:10)
+2024-05-01 12:00:02 WARN [Slow] user=bob took=2.5 slow checkout
+`)
+
+	args := []string{"tail", "-once", "-from-start", "-state", state, "-pattern", testLogPattern, log}
+	if code, _, stderr := runCommand("", args...); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	events := api.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+	payment, slow := events[0], events[1]
+	if payment.LogLevel != "error" || payment.EventType != "PaymentError" || payment.EventUser != "alice" {
+		t.Errorf("unexpected event %q %q %q", payment.LogLevel, payment.EventType, payment.EventUser)
+	}
+	if payment.EventMessage != "charge failed\n\tat billing.Charge(billing.go:42)\n\tat main.main(main.go:10)" {
+		t.Errorf("the stack trace was not grouped: %q", payment.EventMessage)
+	}
+	absolute, _ := filepath.Abs(log)
+	if !hasTag(payment, "log.file:"+absolute) {
+		t.Errorf("unexpected tags %v", payment.ContextTags)
+	}
+	if slow.LogLevel != "warn" || slow.EventMessage != "slow checkout" || slow.CustomProperties.DoubleData.CustomData1 != 2.5 {
+		t.Errorf("unexpected event %q %q %+v", slow.LogLevel, slow.EventMessage, slow.CustomProperties)
+	}
+
+	appendFile(t, log, "2024-05-01 12:00:03 ERROR [Crash] user=carol out of memory\n2024-05-01 12:00:04 ERROR [Crash] user=dave half a li")
+	if code, _, stderr := runCommand("", args...); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	appendFile(t, log, "ne\n")
+	if code, _, stderr := runCommand("", args...); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	events = api.Events()
+	if len(events) != 4 || events[2].EventUser != "carol" || events[3].EventMessage != "half a line" {
+		t.Errorf("the saved offset was not resumed: %+v", events[2:])
+	}
+}
+
+func newTestTailer(t *testing.T, path string, statePath string) (*tailer, *trakerrtest.Server) {
+	api, url := newTestAPI(t)
+	client := trakerr.NewTrakerrClient("test-key", "1.0", "test")
+	client.SetBaseURL(url)
+	tailer := &tailer{
+		client:       client,
+		patterns:     patternValues{regexp.MustCompile(`^ERROR (?P<message>.*)`)},
+		continuation: regexp.MustCompile(`^\s`),
+		maxLines:     3,
+		level:        "error",
+		eventType:    "LogLine",
+		statePath:    statePath,
+		stderr:       io.Discard,
+	}
+	if err := tailer.open([]string{path}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tailer.close)
+	return tailer, api
+}
+
+func messages(api *trakerrtest.Server) string {
+	var messages []string
+	for _, event := range api.Events() {
+		messages = append(messages, event.EventMessage)
+	}
+	return strings.Join(messages, "|")
+}
+
+func TestTailFollowsRotationAndTruncation(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "app.log")
+	appendFile(t, log, "ERROR before the tailer started\n")
+	tailer, api := newTestTailer(t, log, "")
+
+	appendFile(t, log, "ERROR first\n\tline 2\n\tline 3\n\tline 4\n")
+	if err := tailer.poll(false); err != nil {
+		t.Fatal(err)
+	}
+	if messages(api) != "" {
+		t.Errorf("an event was sent while its stack trace could still grow: %q", messages(api))
+	}
+	if err := tailer.poll(false); err != nil {
+		t.Fatal(err)
+	}
+	if messages(api) != "first\n\tline 2\n\tline 3" {
+		t.Errorf("unexpected events %q", messages(api))
+	}
+
+	appendFile(t, log, "ERROR written before the rotation\n")
+	if err := os.Rename(log, log+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, log, "ERROR after the rotation\n")
+	if err := tailer.poll(true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(messages(api), "|written before the rotation|after the rotation") {
+		t.Errorf("unexpected events after the rotation %q", messages(api))
+	}
+
+	if err := os.WriteFile(log, []byte("ERROR truncated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := tailer.poll(true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(messages(api), "|after the rotation|truncated") {
+		t.Errorf("unexpected events after the truncation %q", messages(api))
+	}
+}
+
+func TestTailResumesAtThePendingEvent(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "app.log")
+	state := filepath.Join(dir, "state.json")
+	appendFile(t, log, "")
+	tailer, api := newTestTailer(t, log, state)
+
+	appendFile(t, log, "ERROR sent\nERROR pending\n\tat first\n")
+	if err := tailer.poll(false); err != nil {
+		t.Fatal(err)
+	}
+	if messages(api) != "sent" {
+		t.Fatalf("unexpected events %q", messages(api))
+	}
+
+	// the tailer stops before the stack trace is complete, without sending the pending event
+	appendFile(t, log, "\tat second\n")
+	restarted, api := newTestTailer(t, log, state)
+	if err := restarted.poll(true); err != nil {
+		t.Fatal(err)
+	}
+	if messages(api) != "pending\n\tat first\n\tat second" {
+		t.Errorf("the pending event was not read again after the restart: %q", messages(api))
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+)
+
+// runValidate checks AppEvent JSON files against the model and prints the problems of each. "-" reads stdin.
+func runValidate(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("validate", stderr)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "usage: trakerr validate <file>...")
+		return errUsage
+	}
+	invalid := 0
+	for _, name := range flags.Args() {
+		if err := validateFile(name, stdin); err != nil {
+			invalid++
+			fmt.Fprintf(stdout, "%s: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: ok\n", name)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files are invalid", invalid, flags.NArg())
+	}
+	return nil
+}
+
+// validateFile decodes and validates the AppEvent in the file called name.
+func validateFile(name string, stdin io.Reader) error {
+	r := stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	appEvent, err := trakerr.DecodeAppEvent(r)
+	if err != nil {
+		return err
+	}
+	return appEvent.Validate()
+}
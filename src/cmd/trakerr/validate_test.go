@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(valid, []byte(`{"apiKey": "key", "classification": "issue", "eventType": "Crash", "eventMessage": "boom", "logLevel": "fatal"}`), 0o644)
+	os.WriteFile(invalid, []byte(`{"apiKey": "key", "eventType": "Crash", "logLevel": "loud"}`), 0o644)
+
+	if code, stdout, stderr := runCommand("", "validate", valid); code != 0 || stdout != valid+": ok\n" {
+		t.Errorf("valid file: exit %d, %q %q", code, stdout, stderr)
+	}
+	code, stdout, stderr := runCommand(`{"eventMessage": "from stdin"}`, "validate", valid, invalid, "-", filepath.Join(dir, "missing.json"))
+	if code != 1 || !strings.Contains(stderr, "3 of 4 files are invalid") {
+		t.Errorf("exit %d, %q", code, stderr)
+	}
+	for _, want := range []string{
+		valid + ": ok",
+		invalid + `: trakerr: invalid AppEvent: classification is required; eventMessage is required; logLevel "loud" is not one of`,
+		"-: trakerr: invalid AppEvent: apiKey is required",
+		"missing.json: open ",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("%q missing from the output:\n%s", want, stdout)
+		}
+	}
+	if code, _, _ := runCommand("", "validate"); code != 2 {
+		t.Errorf("no files: exit %d", code)
+	}
+}
@@ -68,7 +68,7 @@ type TestError struct {
 //BufferOverflowError ...
 func (testError *TestError) BufferOverflowError(buf []int, i int, session TestSession) (x int) {
 	//defer client.Recover()
-	defer session.client.RecoverWithAppEvent("Error", session.appEvent)
+	defer session.client.RecoverWithAppEvent(session.appEvent)
 
 	x = buf[i]
 	return x
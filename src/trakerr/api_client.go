@@ -28,6 +28,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"net/url"
 	"github.com/go-resty/resty"
 )
@@ -35,6 +36,9 @@ import (
 type APIClient struct {
 }
 
+// restyDebugOnce guards the resty package level debug flag, which is shared by every request.
+var restyDebugOnce sync.Once
+
 func (c *APIClient) SelectHeaderContentType(contentTypes []string) string {
 
 	if len(contentTypes) == 0 {
@@ -74,9 +78,11 @@ func (c *APIClient) CallAPI(path string, method string,
 	fileName string,
 	fileBytes []byte) (*resty.Response, error) {
 
-	//set debug flag
-	configuration := NewConfiguration()
-	resty.SetDebug(configuration.GetDebug())
+	//set debug flag once, resty keeps it in a package level client shared between goroutines
+	restyDebugOnce.Do(func() {
+		configuration := NewConfiguration()
+		resty.SetDebug(configuration.GetDebug())
+	})
 
 	request := prepareRequest(postBody, headerParams, queryParams, formParams, fileName, fileBytes)
 
@@ -86,7 +86,22 @@ type AppEvent struct {
 	// (optional) Data center region
 	ContextDataCenterRegion string `json:"contextDataCenterRegion,omitempty"`
 
+	// (optional) tags attached to the event
+	ContextTags []string `json:"contextTags,omitempty"`
+
+	// (optional) cross application correlation ID
+	ContextCrossAppCorrelationId string `json:"contextCrossAppCorrelationId,omitempty"`
+
+	// (optional) URL of the request or page the event occurred in
+	ContextURL string `json:"contextURL,omitempty"`
+
+	// (optional) duration of the operation the event belongs to, in milliseconds
+	ContextOperationTimeMillis int64 `json:"contextOperationTimeMillis,omitempty"`
+
 	CustomProperties CustomData `json:"customProperties,omitempty"`
 
 	CustomSegments CustomData `json:"customSegments,omitempty"`
+
+	// (optional) trail of breadcrumbs recorded before the event, oldest first
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty"`
 }
@@ -0,0 +1,36 @@
+package trakerr
+
+// Copy returns a deep copy of the AppEvent. Slices and maps are duplicated, so the copy
+// can be filled in and sent while the original keeps being used as a template by other goroutines.
+func (appEvent *AppEvent) Copy() *AppEvent {
+	if appEvent == nil {
+		return nil
+	}
+	result := *appEvent
+
+	if appEvent.EventStacktrace != nil {
+		result.EventStacktrace = make([]InnerStackTrace, len(appEvent.EventStacktrace))
+		for i, trace := range appEvent.EventStacktrace {
+			result.EventStacktrace[i] = trace
+			if trace.TraceLines != nil {
+				result.EventStacktrace[i].TraceLines = append([]StackTraceLine(nil), trace.TraceLines...)
+			}
+		}
+	}
+	if appEvent.ContextTags != nil {
+		result.ContextTags = append([]string(nil), appEvent.ContextTags...)
+	}
+	if appEvent.Breadcrumbs != nil {
+		result.Breadcrumbs = make([]Breadcrumb, len(appEvent.Breadcrumbs))
+		for i, crumb := range appEvent.Breadcrumbs {
+			result.Breadcrumbs[i] = crumb
+			if crumb.Data != nil {
+				result.Breadcrumbs[i].Data = make(map[string]string, len(crumb.Data))
+				for key, value := range crumb.Data {
+					result.Breadcrumbs[i].Data[key] = value
+				}
+			}
+		}
+	}
+	return &result
+}
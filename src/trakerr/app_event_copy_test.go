@@ -0,0 +1,49 @@
+package trakerr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppEventCopyIsDeep(t *testing.T) {
+	event := &AppEvent{
+		EventType: "Type",
+		EventStacktrace: []InnerStackTrace{{
+			Type_:      "Type",
+			TraceLines: []StackTraceLine{{Function: "main.main", Line: 1, File: "main.go"}},
+		}},
+		ContextTags: []string{"a:b"},
+		Breadcrumbs: []Breadcrumb{{Message: "crumb", Data: map[string]string{"key": "value"}}},
+	}
+	original := &AppEvent{
+		EventType: "Type",
+		EventStacktrace: []InnerStackTrace{{
+			Type_:      "Type",
+			TraceLines: []StackTraceLine{{Function: "main.main", Line: 1, File: "main.go"}},
+		}},
+		ContextTags: []string{"a:b"},
+		Breadcrumbs: []Breadcrumb{{Message: "crumb", Data: map[string]string{"key": "value"}}},
+	}
+
+	copied := event.Copy()
+	if !reflect.DeepEqual(copied, event) {
+		t.Fatalf("copy differs from the original: %+v", copied)
+	}
+
+	copied.EventStacktrace[0].TraceLines[0].Function = "changed"
+	copied.EventStacktrace[0].Type_ = "changed"
+	copied.ContextTags[0] = "changed"
+	copied.Breadcrumbs[0].Data["key"] = "changed"
+	copied.Breadcrumbs[0].Message = "changed"
+
+	if !reflect.DeepEqual(event, original) {
+		t.Errorf("changing the copy changed the original: %+v", event)
+	}
+}
+
+func TestAppEventCopyOfNil(t *testing.T) {
+	var event *AppEvent
+	if event.Copy() != nil {
+		t.Error("expected nil")
+	}
+}
@@ -0,0 +1,81 @@
+package trakerr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// logLevels are the log levels the events API accepts. "warn" is sent by clients as a synonym of "warning".
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "warning": true, "error": true, "fatal": true}
+
+// ValidationError lists the ways an AppEvent doesn't match the AppEvent model of the events API.
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return "trakerr: invalid AppEvent: " + strings.Join(err.Problems, "; ")
+}
+
+// DecodeAppEvent decodes a single JSON AppEvent from r. Fields that are not in the AppEvent model and data after
+// the event are errors. The event isn't validated, so templates missing the required fields decode too.
+func DecodeAppEvent(r io.Reader) (*AppEvent, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var appEvent AppEvent
+	if err := decoder.Decode(&appEvent); err != nil {
+		return nil, fmt.Errorf("trakerr: cannot decode AppEvent: %w", err)
+	}
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return nil, fmt.Errorf("trakerr: cannot decode AppEvent: unexpected data after the event")
+	}
+	return &appEvent, nil
+}
+
+// Validate checks the event against the AppEvent model of the events API: the API key, classification, event type
+// and message are required, the log level is one of the model's and times are not negative. It returns a
+// *ValidationError listing every problem, or nil.
+func (appEvent *AppEvent) Validate() error {
+	var problems []string
+	for _, field := range []struct{ name, value string }{
+		{"apiKey", appEvent.ApiKey},
+		{"classification", appEvent.Classification},
+		{"eventType", appEvent.EventType},
+		{"eventMessage", appEvent.EventMessage},
+	} {
+		if field.value == "" {
+			problems = append(problems, field.name+" is required")
+		}
+	}
+	if appEvent.LogLevel != "" && !logLevels[appEvent.LogLevel] {
+		problems = append(problems, fmt.Sprintf("logLevel %q is not one of debug, info, warning, error, fatal", appEvent.LogLevel))
+	}
+	if appEvent.EventTime < 0 {
+		problems = append(problems, "eventTime is negative")
+	}
+	if appEvent.ContextOperationTimeMillis < 0 {
+		problems = append(problems, "contextOperationTimeMillis is negative")
+	}
+	for i, tag := range appEvent.ContextTags {
+		if tag == "" {
+			problems = append(problems, fmt.Sprintf("contextTags[%d] is empty", i))
+		}
+	}
+	for i, trace := range appEvent.EventStacktrace {
+		if trace.Type_ == "" {
+			problems = append(problems, fmt.Sprintf("eventStacktrace[%d].type is required", i))
+		}
+	}
+	for i, crumb := range appEvent.Breadcrumbs {
+		if crumb.Level != "" && !logLevels[crumb.Level] {
+			problems = append(problems, fmt.Sprintf("breadcrumbs[%d].level %q is not one of debug, info, warning, error, fatal", i, crumb.Level))
+		}
+	}
+	if problems != nil {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package trakerr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAppEvent(t *testing.T) {
+	appEvent, err := DecodeAppEvent(strings.NewReader(`{"logLevel": "warning", "eventMessage": "disk full", "customProperties": {"stringData": {"customData2": "sda1"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appEvent.LogLevel != "warning" || appEvent.EventMessage != "disk full" || appEvent.CustomProperties.StringData.CustomData2 != "sda1" {
+		t.Errorf("unexpected event %+v", appEvent)
+	}
+
+	for _, invalid := range []string{
+		``,
+		`{"eventMesage": "typo"}`,
+		`{"eventTime": "yesterday"}`,
+		`{"eventMessage": "one"} {"eventMessage": "two"}`,
+	} {
+		if _, err := DecodeAppEvent(strings.NewReader(invalid)); err == nil {
+			t.Errorf("DecodeAppEvent(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	client := NewTrakerrClient("test-api-key", "1.0", "test")
+	if err := client.NewAppEvent("warn", "", "", "").Validate(); err != nil {
+		t.Errorf("an event of the client is invalid: %v", err)
+	}
+
+	appEvent := &AppEvent{
+		LogLevel:        "verbose",
+		EventType:       "Crash",
+		EventTime:       -1,
+		ContextTags:     []string{"a:b", ""},
+		EventStacktrace: []InnerStackTrace{{Message: "no type"}},
+		Breadcrumbs:     []Breadcrumb{{Level: "loud"}},
+	}
+	err := appEvent.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a *ValidationError, got %v", err)
+	}
+	want := []string{
+		"apiKey is required",
+		"classification is required",
+		"eventMessage is required",
+		`logLevel "verbose" is not one of debug, info, warning, error, fatal`,
+		"eventTime is negative",
+		"contextTags[1] is empty",
+		"eventStacktrace[0].type is required",
+		`breadcrumbs[0].level "loud" is not one of debug, info, warning, error, fatal`,
+	}
+	if strings.Join(validationErr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(validationErr.Problems, "\n"))
+	}
+}
+
+func TestCustomDataSlots(t *testing.T) {
+	var customData CustomData
+	customData.SetString(3, "eu-west")
+	customData.SetDouble(10, 2.5)
+	if customData.StringData.CustomData3 != "eu-west" || customData.DoubleData.CustomData10 != 2.5 {
+		t.Errorf("unexpected custom data %+v", customData)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("slot 11 was accepted")
+		}
+	}()
+	customData.SetString(11, "out of range")
+}
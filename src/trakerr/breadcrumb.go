@@ -0,0 +1,242 @@
+package trakerr
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBreadcrumbs is the number of breadcrumbs kept by a TrakerrClient or a context scope
+// before the oldest entries start being overwritten.
+const DefaultMaxBreadcrumbs = 100
+
+// Breadcrumb categories used by the built in hooks.
+const (
+	BreadcrumbCategoryDefault = "default"
+	BreadcrumbCategoryHTTP    = "http"
+	BreadcrumbCategorySQL     = "query"
+	BreadcrumbCategoryLog     = "log"
+)
+
+// Breadcrumb is a single entry in the trail of things the program did before an event was reported.
+type Breadcrumb struct {
+
+	// time the breadcrumb was recorded in ms since epoch
+	Timestamp int64 `json:"timestamp,omitempty"`
+
+	// category of the breadcrumb (eg. http, query, log)
+	Category string `json:"category,omitempty"`
+
+	// one of 'debug','info','warning','error', 'fatal'
+	Level string `json:"level,omitempty"`
+
+	// human readable description of what happened
+	Message string `json:"message,omitempty"`
+
+	// additional key/value data for the breadcrumb
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// BreadcrumbBuffer is a bounded, goroutine-safe ring buffer of breadcrumbs.
+// Once the buffer is full, adding a breadcrumb overwrites the oldest one.
+type BreadcrumbBuffer struct {
+	mu     sync.Mutex
+	crumbs []Breadcrumb
+	start  int
+	size   int
+}
+
+// NewBreadcrumbBuffer returns a BreadcrumbBuffer that holds at most capacity breadcrumbs.
+// A capacity of zero or less uses DefaultMaxBreadcrumbs.
+func NewBreadcrumbBuffer(capacity int) *BreadcrumbBuffer {
+	if capacity <= 0 {
+		capacity = DefaultMaxBreadcrumbs
+	}
+	return &BreadcrumbBuffer{crumbs: make([]Breadcrumb, capacity)}
+}
+
+// Add records a breadcrumb, filling in the timestamp, category and level if they are empty.
+func (b *BreadcrumbBuffer) Add(crumb Breadcrumb) {
+	if crumb.Timestamp <= 0 {
+		crumb.Timestamp = makeTimestamp()
+	}
+	if crumb.Category == "" {
+		crumb.Category = BreadcrumbCategoryDefault
+	}
+	if crumb.Level == "" {
+		crumb.Level = "info"
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	end := (b.start + b.size) % len(b.crumbs)
+	b.crumbs[end] = crumb
+	if b.size < len(b.crumbs) {
+		b.size++
+	} else {
+		b.start = (b.start + 1) % len(b.crumbs)
+	}
+}
+
+// Snapshot returns a copy of the buffered breadcrumbs, oldest first.
+func (b *BreadcrumbBuffer) Snapshot() []Breadcrumb {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.snapshot()
+}
+
+func (b *BreadcrumbBuffer) snapshot() []Breadcrumb {
+	if b.size == 0 {
+		return nil
+	}
+	result := make([]Breadcrumb, b.size)
+	for i := 0; i < b.size; i++ {
+		result[i] = b.crumbs[(b.start+i)%len(b.crumbs)]
+	}
+	return result
+}
+
+// Cap returns the maximum number of breadcrumbs the buffer holds.
+func (b *BreadcrumbBuffer) Cap() int {
+	return len(b.crumbs)
+}
+
+// Len returns the number of buffered breadcrumbs.
+func (b *BreadcrumbBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Clear removes all buffered breadcrumbs.
+func (b *BreadcrumbBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clear()
+}
+
+// Drain returns the buffered breadcrumbs, oldest first, and removes them from the buffer in one step.
+func (b *BreadcrumbBuffer) Drain() []Breadcrumb {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := b.snapshot()
+	b.clear()
+	return result
+}
+
+func (b *BreadcrumbBuffer) clear() {
+	for i := range b.crumbs {
+		b.crumbs[i] = Breadcrumb{}
+	}
+	b.start = 0
+	b.size = 0
+}
+
+// WithBreadcrumbs forks the scope carried by ctx with a fresh breadcrumb trail and returns a context carrying it.
+// Breadcrumbs added through the Context variants of the TrakerrClient hooks are recorded there instead of on
+// the client, so concurrent requests don't see each other's trail. A capacity of zero or less uses DefaultMaxBreadcrumbs.
+func WithBreadcrumbs(ctx context.Context, capacity int) context.Context {
+	ctx, scope := WithScope(ctx)
+	scope.breadcrumbs = NewBreadcrumbBuffer(capacity)
+	return ctx
+}
+
+// breadcrumbsFromContext returns the breadcrumb trail of the scope stored in ctx, or nil if there is none.
+func breadcrumbsFromContext(ctx context.Context) *BreadcrumbBuffer {
+	if scope := ScopeFromContext(ctx); scope != nil {
+		return scope.trail()
+	}
+	return nil
+}
+
+// breadcrumbBuffer returns the trail breadcrumbs for ctx should be read from and written to.
+func (trakerrClient *TrakerrClient) breadcrumbBuffer(ctx context.Context) *BreadcrumbBuffer {
+	if buffer := breadcrumbsFromContext(ctx); buffer != nil {
+		return buffer
+	}
+	return trakerrClient.breadcrumbs
+}
+
+// AddBreadcrumb records a breadcrumb on the client wide trail.
+func (trakerrClient *TrakerrClient) AddBreadcrumb(crumb Breadcrumb) {
+	trakerrClient.breadcrumbs.Add(crumb)
+}
+
+// AddBreadcrumbContext records a breadcrumb on the trail carried by ctx, falling back to the client wide trail.
+func (trakerrClient *TrakerrClient) AddBreadcrumbContext(ctx context.Context, crumb Breadcrumb) {
+	trakerrClient.breadcrumbBuffer(ctx).Add(crumb)
+}
+
+// AddHTTPBreadcrumb records an outgoing or incoming HTTP exchange. A statusCode of zero means no response was received.
+func (trakerrClient *TrakerrClient) AddHTTPBreadcrumb(ctx context.Context, method string, url string, statusCode int, duration time.Duration) {
+	level := "info"
+	data := map[string]string{
+		"method":      method,
+		"url":         url,
+		"duration_ms": strconv.FormatInt(int64(duration/time.Millisecond), 10),
+	}
+	if statusCode > 0 {
+		data["status_code"] = strconv.Itoa(statusCode)
+	}
+	if statusCode == 0 || statusCode >= 500 {
+		level = "error"
+	} else if statusCode >= 400 {
+		level = "warning"
+	}
+	trakerrClient.AddBreadcrumbContext(ctx, Breadcrumb{
+		Category: BreadcrumbCategoryHTTP,
+		Level:    level,
+		Message:  method + " " + url,
+		Data:     data,
+	})
+}
+
+// AddSQLBreadcrumb records a database query along with how long it took and the error it returned, if any.
+func (trakerrClient *TrakerrClient) AddSQLBreadcrumb(ctx context.Context, query string, duration time.Duration, err error) {
+	level := "info"
+	data := map[string]string{
+		"duration_ms": strconv.FormatInt(int64(duration/time.Millisecond), 10),
+	}
+	if err != nil {
+		level = "error"
+		data["error"] = err.Error()
+	}
+	trakerrClient.AddBreadcrumbContext(ctx, Breadcrumb{
+		Category: BreadcrumbCategorySQL,
+		Level:    level,
+		Message:  query,
+		Data:     data,
+	})
+}
+
+// AddLogBreadcrumb records a log statement that was not itself worth sending as an event.
+func (trakerrClient *TrakerrClient) AddLogBreadcrumb(ctx context.Context, loglevel string, message string) {
+	trakerrClient.AddBreadcrumbContext(ctx, Breadcrumb{
+		Category: BreadcrumbCategoryLog,
+		Level:    breadcrumbLevel(loglevel),
+		Message:  message,
+	})
+}
+
+// breadcrumbLevel lowercases a log level for a breadcrumb, spelling "warn" out as "warning".
+// Unlike event levels, unknown levels are kept as they are instead of becoming "error".
+func breadcrumbLevel(loglevel string) string {
+	loglevel = strings.ToLower(loglevel)
+	switch loglevel {
+	case "":
+		return "info"
+	case "warn":
+		return "warning"
+	}
+	return loglevel
+}
+
+// AttachBreadcrumbs copies the breadcrumb trail for ctx onto appEvent, unless the event already carries one.
+func (trakerrClient *TrakerrClient) AttachBreadcrumbs(ctx context.Context, appEvent *AppEvent) {
+	if appEvent.Breadcrumbs != nil {
+		return
+	}
+	appEvent.Breadcrumbs = trakerrClient.breadcrumbBuffer(ctx).Snapshot()
+}
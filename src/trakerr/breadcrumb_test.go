@@ -0,0 +1,146 @@
+package trakerr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestBreadcrumbBufferWrapsAround(t *testing.T) {
+	buffer := NewBreadcrumbBuffer(3)
+	for i := 1; i <= 5; i++ {
+		buffer.Add(Breadcrumb{Message: fmt.Sprint(i)})
+	}
+
+	crumbs := buffer.Snapshot()
+	if len(crumbs) != 3 || buffer.Len() != 3 {
+		t.Fatalf("expected 3 breadcrumbs, got %d (Len %d)", len(crumbs), buffer.Len())
+	}
+	for i, want := range []string{"3", "4", "5"} {
+		if crumbs[i].Message != want {
+			t.Errorf("breadcrumb %d: expected %q, got %q", i, want, crumbs[i].Message)
+		}
+	}
+}
+
+func TestBreadcrumbBufferFillsDefaults(t *testing.T) {
+	buffer := NewBreadcrumbBuffer(1)
+	buffer.Add(Breadcrumb{Message: "hello"})
+
+	crumb := buffer.Snapshot()[0]
+	if crumb.Timestamp <= 0 || crumb.Category != BreadcrumbCategoryDefault || crumb.Level != "info" {
+		t.Errorf("defaults not filled in: %+v", crumb)
+	}
+}
+
+func TestBreadcrumbBufferDefaultCapacity(t *testing.T) {
+	for _, capacity := range []int{0, -1} {
+		if got := NewBreadcrumbBuffer(capacity).Cap(); got != DefaultMaxBreadcrumbs {
+			t.Errorf("capacity %d: expected %d, got %d", capacity, DefaultMaxBreadcrumbs, got)
+		}
+	}
+}
+
+func TestBreadcrumbBufferClear(t *testing.T) {
+	buffer := NewBreadcrumbBuffer(2)
+	buffer.Add(Breadcrumb{Message: "a"})
+	buffer.Add(Breadcrumb{Message: "b"})
+	buffer.Add(Breadcrumb{Message: "c"})
+	buffer.Clear()
+	if buffer.Snapshot() != nil {
+		t.Fatalf("expected an empty snapshot after Clear")
+	}
+	buffer.Add(Breadcrumb{Message: "d"})
+	if crumbs := buffer.Snapshot(); len(crumbs) != 1 || crumbs[0].Message != "d" {
+		t.Errorf("unexpected breadcrumbs after Clear: %+v", crumbs)
+	}
+}
+
+func TestAddHTTPBreadcrumbLevels(t *testing.T) {
+	client := NewTrakerrClient("key", "", "")
+	for _, test := range []struct {
+		statusCode int
+		level      string
+	}{
+		{0, "error"},
+		{200, "info"},
+		{302, "info"},
+		{404, "warning"},
+		{499, "warning"},
+		{500, "error"},
+		{503, "error"},
+	} {
+		ctx := WithBreadcrumbs(context.Background(), 1)
+		client.AddHTTPBreadcrumb(ctx, "GET", "http://example.com", test.statusCode, 0)
+		crumb := ScopeFromContext(ctx).Breadcrumbs()[0]
+		if crumb.Level != test.level {
+			t.Errorf("status %d: expected level %q, got %q", test.statusCode, test.level, crumb.Level)
+		}
+		if _, ok := crumb.Data["status_code"]; ok != (test.statusCode > 0) {
+			t.Errorf("status %d: unexpected status_code data %+v", test.statusCode, crumb.Data)
+		}
+	}
+}
+
+func TestAddLogBreadcrumbLevels(t *testing.T) {
+	client := NewTrakerrClient("key", "", "")
+	for _, test := range []struct {
+		loglevel string
+		level    string
+	}{
+		{"warn", "warning"},
+		{"WARNING", "warning"},
+		{"Debug", "debug"},
+		{"", "info"},
+		{"trace", "trace"},
+	} {
+		ctx := WithBreadcrumbs(context.Background(), 1)
+		client.AddLogBreadcrumb(ctx, test.loglevel, "message")
+		if got := ScopeFromContext(ctx).Breadcrumbs()[0].Level; got != test.level {
+			t.Errorf("level %q: expected %q, got %q", test.loglevel, test.level, got)
+		}
+	}
+}
+
+func TestContextBreadcrumbsStaySeparateFromClient(t *testing.T) {
+	client := NewTrakerrClient("key", "", "")
+	ctx := WithBreadcrumbs(context.Background(), 0)
+	client.AddBreadcrumb(Breadcrumb{Message: "client"})
+	client.AddBreadcrumbContext(ctx, Breadcrumb{Message: "request"})
+
+	event := &AppEvent{}
+	client.AttachBreadcrumbs(ctx, event)
+	if len(event.Breadcrumbs) != 1 || event.Breadcrumbs[0].Message != "request" {
+		t.Errorf("expected only the request breadcrumb, got %+v", event.Breadcrumbs)
+	}
+	event = &AppEvent{}
+	client.AttachBreadcrumbs(context.Background(), event)
+	if len(event.Breadcrumbs) != 1 || event.Breadcrumbs[0].Message != "client" {
+		t.Errorf("expected only the client breadcrumb, got %+v", event.Breadcrumbs)
+	}
+}
+
+func TestSendEventRefreshesBreadcrumbsOnResend(t *testing.T) {
+	client, recorder := newTestClient(t)
+	event := client.NewAppEvent("info", "", "Type", "message")
+
+	client.AddBreadcrumb(Breadcrumb{Message: "first"})
+	if _, err := client.SendEvent(event); err != nil {
+		t.Fatal(err)
+	}
+	client.AddBreadcrumb(Breadcrumb{Message: "second"})
+	if _, err := client.SendEvent(event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Breadcrumbs != nil {
+		t.Errorf("SendEvent modified the caller's event: %+v", event.Breadcrumbs)
+	}
+	events := recorder.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if len(events[0].Breadcrumbs) != 1 || len(events[1].Breadcrumbs) != 2 {
+		t.Errorf("expected trails of 1 and 2 breadcrumbs, got %+v and %+v", events[0].Breadcrumbs, events[1].Breadcrumbs)
+	}
+}
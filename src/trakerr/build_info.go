@@ -0,0 +1,135 @@
+package trakerr
+
+import (
+	"runtime/debug"
+	"sort"
+	"sync"
+)
+
+// BuildInfo is the version information the Go toolchain embedded in the running binary.
+type BuildInfo struct {
+
+	// main module path (eg. github.com/example/app)
+	Path string
+
+	// main module version (eg. v1.4.2), empty for "(devel)" builds
+	Version string
+
+	// VCS commit the binary was built from (vcs.revision)
+	Revision string
+
+	// commit time in RFC3339 format (vcs.time)
+	Time string
+
+	// true if the working tree had uncommitted changes (vcs.modified)
+	Modified bool
+
+	// module path and version of every dependency, replacements applied
+	Dependencies []Dependency
+}
+
+// Dependency is a module the binary was built with.
+type Dependency struct {
+	Path    string
+	Version string
+}
+
+var (
+	buildInfoOnce sync.Once
+	buildInfo     BuildInfo
+)
+
+// CurrentBuildInfo returns the build information of the running binary, read once with debug.ReadBuildInfo.
+// It is empty for binaries built without module support.
+func CurrentBuildInfo() BuildInfo {
+	buildInfoOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			buildInfo = newBuildInfo(info)
+		}
+	})
+	return buildInfo
+}
+
+func newBuildInfo(info *debug.BuildInfo) BuildInfo {
+	result := BuildInfo{Path: info.Main.Path}
+	if info.Main.Version != "(devel)" {
+		result.Version = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			result.Revision = setting.Value
+		case "vcs.time":
+			result.Time = setting.Value
+		case "vcs.modified":
+			result.Modified = setting.Value == "true"
+		}
+	}
+	for _, module := range info.Deps {
+		if module.Replace != nil {
+			module = module.Replace
+		}
+		result.Dependencies = append(result.Dependencies, Dependency{Path: module.Path, Version: module.Version})
+	}
+	sort.Slice(result.Dependencies, func(i, j int) bool { return result.Dependencies[i].Path < result.Dependencies[j].Path })
+	return result
+}
+
+// AppVersion returns the version to report for the application: the module version, else the first 12
+// characters of the VCS revision (with a "-dirty" suffix for modified trees), else "".
+func (info BuildInfo) AppVersion() string {
+	if info.Version != "" {
+		return info.Version
+	}
+	if info.Revision == "" {
+		return ""
+	}
+	version := info.Revision
+	if len(version) > 12 {
+		version = version[:12]
+	}
+	if info.Modified {
+		version += "-dirty"
+	}
+	return version
+}
+
+// Tags returns the VCS settings as event tags (vcs.revision, vcs.time and vcs.modified), skipping the missing ones.
+func (info BuildInfo) Tags() map[string]string {
+	tags := make(map[string]string)
+	if info.Revision != "" {
+		tags["vcs.revision"] = info.Revision
+		if info.Modified {
+			tags["vcs.modified"] = "true"
+		} else {
+			tags["vcs.modified"] = "false"
+		}
+	}
+	if info.Time != "" {
+		tags["vcs.time"] = info.Time
+	}
+	return tags
+}
+
+// DependencyTags returns one "dep.<module path>" tag per dependency, with the module version as value.
+func (info BuildInfo) DependencyTags() map[string]string {
+	tags := make(map[string]string, len(info.Dependencies))
+	for _, dependency := range info.Dependencies {
+		tags["dep."+dependency.Path] = dependency.Version
+	}
+	return tags
+}
+
+// IncludeDependencies adds (or, with false, removes) the dependency list of the binary to the tags of every event,
+// so errors can be correlated with dependency upgrades. It is off by default because it can add hundreds of tags.
+func (trakerrClient *TrakerrClient) IncludeDependencies(include bool) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	for key, value := range CurrentBuildInfo().DependencyTags() {
+		if include {
+			trakerrClient.contextTags[key] = value
+		} else {
+			delete(trakerrClient.contextTags, key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package trakerr
+
+import (
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	info := newBuildInfo(&debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/go-resty/resty", Version: "v1.12.0"},
+			{Path: "example.com/forked", Version: "v1.0.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.0.1"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	if info.Version != "" {
+		t.Errorf("expected (devel) to be dropped, got %q", info.Version)
+	}
+	if got := info.AppVersion(); got != "0123456789ab-dirty" {
+		t.Errorf("unexpected app version %q", got)
+	}
+	wantTags := map[string]string{"vcs.revision": "0123456789abcdef0123", "vcs.time": "2024-05-01T10:00:00Z", "vcs.modified": "true"}
+	if !reflect.DeepEqual(info.Tags(), wantTags) {
+		t.Errorf("expected tags %v, got %v", wantTags, info.Tags())
+	}
+	wantDeps := map[string]string{"dep.example.com/fork": "v1.0.1", "dep.github.com/go-resty/resty": "v1.12.0"}
+	if !reflect.DeepEqual(info.DependencyTags(), wantDeps) {
+		t.Errorf("expected dependency tags %v, got %v", wantDeps, info.DependencyTags())
+	}
+}
+
+func TestBuildInfoAppVersionPrefersModuleVersion(t *testing.T) {
+	info := newBuildInfo(&debug.BuildInfo{
+		Main:     debug.Module{Path: "example.com/app", Version: "v1.4.2"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc"}},
+	})
+	if got := info.AppVersion(); got != "v1.4.2" {
+		t.Errorf("expected the module version, got %q", got)
+	}
+	if got := (BuildInfo{}).AppVersion(); got != "" {
+		t.Errorf("expected no version without build info, got %q", got)
+	}
+}
@@ -0,0 +1,200 @@
+package trakerr
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by SendEvent when the event was dropped because the circuit breaker in front of
+// the Trakerr API is open.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit open", ErrEventDropped)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every event through.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen drops every event until the open timeout has passed.
+	CircuitOpen
+
+	// CircuitHalfOpen lets one probe event through at a time to find out whether Trakerr has recovered.
+	CircuitHalfOpen
+)
+
+// String returns the state in lower case, eg. "half-open".
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(state))
+}
+
+// CircuitBreakerConfig configures the circuit breaker of a TrakerrClient. Zero values use the defaults.
+type CircuitBreakerConfig struct {
+
+	// consecutive failed sends that open the circuit, defaults to 5
+	FailureThreshold int
+
+	// time the circuit stays open before a probe is let through, defaults to 30 seconds
+	OpenTimeout time.Duration
+
+	// consecutive successful probes that close a half-open circuit, defaults to 1
+	SuccessThreshold int
+
+	// called after every state change, outside of the breaker's lock
+	OnStateChange func(from CircuitState, to CircuitState)
+}
+
+// circuitBreaker stops sending to Trakerr after FailureThreshold consecutive failures. A send fails when the
+// transport returns an error or Trakerr answers 429 or 5xx.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	config    CircuitBreakerConfig
+	state     CircuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = 1
+	}
+	return &circuitBreaker{config: config}
+}
+
+// allow reports whether an event may be sent now. In the half-open state only one probe is in flight at a time.
+func (breaker *circuitBreaker) allow(now time.Time) bool {
+	breaker.mu.Lock()
+	from := breaker.state
+	allowed := true
+	switch breaker.state {
+	case CircuitOpen:
+		if now.Sub(breaker.openedAt) < breaker.config.OpenTimeout {
+			allowed = false
+			break
+		}
+		breaker.state = CircuitHalfOpen
+		breaker.successes = 0
+		breaker.probing = true
+	case CircuitHalfOpen:
+		if breaker.probing {
+			allowed = false
+		} else {
+			breaker.probing = true
+		}
+	}
+	to := breaker.state
+	breaker.mu.Unlock()
+
+	breaker.notify(from, to)
+	return allowed
+}
+
+// release gives back the probe allow let through when the send didn't happen after all, eg. because the rate
+// limiter dropped the event.
+func (breaker *circuitBreaker) release() {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	if breaker.state == CircuitHalfOpen {
+		breaker.probing = false
+	}
+}
+
+// record updates the breaker with the outcome of a send that allow let through.
+func (breaker *circuitBreaker) record(success bool, now time.Time) {
+	breaker.mu.Lock()
+	from := breaker.state
+	switch breaker.state {
+	case CircuitClosed:
+		if success {
+			breaker.failures = 0
+		} else if breaker.failures++; breaker.failures >= breaker.config.FailureThreshold {
+			breaker.open(now)
+		}
+	case CircuitHalfOpen:
+		breaker.probing = false
+		if !success {
+			breaker.open(now)
+		} else if breaker.successes++; breaker.successes >= breaker.config.SuccessThreshold {
+			breaker.state = CircuitClosed
+			breaker.failures = 0
+		}
+	}
+	to := breaker.state
+	breaker.mu.Unlock()
+
+	breaker.notify(from, to)
+}
+
+func (breaker *circuitBreaker) open(now time.Time) {
+	breaker.state = CircuitOpen
+	breaker.openedAt = now
+	breaker.failures = 0
+	breaker.probing = false
+}
+
+func (breaker *circuitBreaker) notify(from CircuitState, to CircuitState) {
+	if from != to && breaker.config.OnStateChange != nil {
+		breaker.config.OnStateChange(from, to)
+	}
+}
+
+func (breaker *circuitBreaker) currentState() CircuitState {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	return breaker.state
+}
+
+// sendFailed reports whether the outcome of a send counts as a failure of the Trakerr API.
+func sendFailed(response *APIResponse, err error) bool {
+	if err != nil {
+		return true
+	}
+	if response == nil || response.Response == nil {
+		return false
+	}
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+}
+
+// SetCircuitBreaker puts a circuit breaker in front of the Trakerr API, so a degraded Trakerr isn't sent
+// an event per error. While the circuit is open, events are dropped and SendEvent returns ErrCircuitOpen.
+func (trakerrClient *TrakerrClient) SetCircuitBreaker(config CircuitBreakerConfig) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.breaker = newCircuitBreaker(config)
+}
+
+// RemoveCircuitBreaker removes the circuit breaker set with SetCircuitBreaker.
+func (trakerrClient *TrakerrClient) RemoveCircuitBreaker() {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.breaker = nil
+}
+
+// CircuitState returns the state of the circuit breaker, CircuitClosed if there is none.
+func (trakerrClient *TrakerrClient) CircuitState() CircuitState {
+	trakerrClient.mu.RLock()
+	breaker := trakerrClient.breaker
+	trakerrClient.mu.RUnlock()
+	if breaker == nil {
+		return CircuitClosed
+	}
+	return breaker.currentState()
+}
@@ -0,0 +1,118 @@
+package trakerr
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	var transitions []string
+	breaker := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		SuccessThreshold: 2,
+		OnStateChange: func(from CircuitState, to CircuitState) {
+			transitions = append(transitions, from.String()+">"+to.String())
+		},
+	})
+	start := time.Unix(0, 0)
+
+	breaker.allow(start)
+	breaker.record(false, start)
+	breaker.allow(start)
+	breaker.record(false, start)
+	if breaker.allow(start.Add(time.Second)) {
+		t.Fatal("expected the open circuit to drop events")
+	}
+
+	probe := start.Add(2 * time.Minute)
+	if !breaker.allow(probe) {
+		t.Fatal("expected a probe after the open timeout")
+	}
+	if breaker.allow(probe) {
+		t.Fatal("expected only one probe in flight")
+	}
+	breaker.record(false, probe)
+	if breaker.allow(probe.Add(time.Second)) {
+		t.Fatal("expected a failed probe to reopen the circuit")
+	}
+
+	probe = probe.Add(2 * time.Minute)
+	for i := 0; i < 2; i++ {
+		if !breaker.allow(probe) {
+			t.Fatalf("expected probe %d to be let through", i)
+		}
+		breaker.record(true, probe)
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("expected transitions %v, got %v", want, transitions)
+	}
+}
+
+func TestClientCircuitBreakerOpensOnServerErrors(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t)
+	client.eventsAPI = *NewEventsApiWithBasePath(server.URL)
+	client.SetCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 3, OpenTimeout: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if response, err := client.SendEvent(client.NewEmptyEvent()); err != nil || response.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("unexpected result %v, %v", response, err)
+		}
+	}
+	if client.CircuitState() != CircuitOpen {
+		t.Fatalf("expected the circuit to be open, got %v", client.CircuitState())
+	}
+	if _, err := client.SendEvent(client.NewEmptyEvent()); !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrEventDropped) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	func() {
+		defer client.Recover("error", "")
+		panic("while open")
+	}()
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+}
+
+func TestOpenCircuitKeepsTheRateBudget(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetRateLimit(0.001, 1)
+	client.SetCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+	client.breaker.open(time.Now())
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.SendEvent(client.NewEmptyEvent()); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected ErrCircuitOpen, got %v", err)
+		}
+	}
+
+	client.breaker.open(time.Now().Add(-2 * time.Hour))
+	if _, err := client.SendEvent(client.NewEmptyEvent()); err != nil {
+		t.Fatalf("expected the probe to use the token the dropped events left, got %v", err)
+	}
+	if len(recorder.Events()) != 1 || client.CircuitState() != CircuitClosed {
+		t.Errorf("expected the probe to be sent and close the circuit, got %d events, %v", len(recorder.Events()), client.CircuitState())
+	}
+
+	client.breaker.open(time.Now().Add(-2 * time.Hour))
+	if _, err := client.SendEvent(client.NewEmptyEvent()); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected the probe to be rate limited, got %v", err)
+	}
+	if !client.breaker.allow(time.Now()) {
+		t.Error("expected a rate limited probe to give its slot back")
+	}
+}
@@ -0,0 +1,259 @@
+package trakerr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultCloudMetadataTimeout bounds each request to an instance metadata endpoint. The endpoints are link-local,
+// so anything slower means the application isn't running on that cloud.
+const DefaultCloudMetadataTimeout = 500 * time.Millisecond
+
+// Default instance metadata base URLs.
+const (
+	DefaultAWSMetadataURL   = "http://169.254.169.254"
+	DefaultGCPMetadataURL   = "http://metadata.google.internal"
+	DefaultAzureMetadataURL = "http://169.254.169.254"
+)
+
+// ErrNoCloudMetadata is returned by DetectCloudMetadata when none of the detectors found an instance metadata endpoint.
+var ErrNoCloudMetadata = errors.New("trakerr: no cloud instance metadata found")
+
+// CloudMetadata describes the cloud instance the application is running on.
+type CloudMetadata struct {
+
+	// cloud provider (aws, gcp or azure), sent as ContextDataCenter
+	Provider string
+
+	// region of the instance (eg. us-east-1), sent as ContextDataCenterRegion
+	Region string
+
+	// availability zone of the instance (eg. us-east-1a)
+	Zone string
+
+	// ID of the instance
+	InstanceID string
+}
+
+// Tags returns the zone, instance ID and provider as event tags, skipping the ones that are empty.
+func (metadata CloudMetadata) Tags() map[string]string {
+	tags := make(map[string]string)
+	for key, value := range map[string]string{
+		"cloud.provider":    metadata.Provider,
+		"cloud.zone":        metadata.Zone,
+		"cloud.instance_id": metadata.InstanceID,
+	} {
+		if value != "" {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// CloudDetector reads the instance metadata of one cloud provider.
+type CloudDetector interface {
+	DetectCloud(ctx context.Context) (CloudMetadata, error)
+}
+
+// AWSDetector reads the EC2 instance identity document using IMDSv2 session tokens.
+type AWSDetector struct {
+
+	// metadata endpoint, defaults to DefaultAWSMetadataURL
+	BaseURL string
+
+	// HTTP client, defaults to http.DefaultClient
+	Client *http.Client
+
+	// timeout of each request, defaults to DefaultCloudMetadataTimeout
+	Timeout time.Duration
+}
+
+// DetectCloud implements CloudDetector.
+func (detector AWSDetector) DetectCloud(ctx context.Context) (CloudMetadata, error) {
+	baseURL := metadataBaseURL(detector.BaseURL, DefaultAWSMetadataURL)
+	token, err := metadataRequest(ctx, detector.Client, detector.Timeout, http.MethodPut, baseURL+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"}, "")
+	if err != nil {
+		return CloudMetadata{}, err
+	}
+	body, err := metadataRequest(ctx, detector.Client, detector.Timeout, http.MethodGet, baseURL+"/latest/dynamic/instance-identity/document",
+		map[string]string{"X-aws-ec2-metadata-token": token}, "")
+	if err != nil {
+		return CloudMetadata{}, err
+	}
+
+	var document struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+	}
+	if err := json.Unmarshal([]byte(body), &document); err != nil {
+		return CloudMetadata{}, fmt.Errorf("trakerr: invalid EC2 instance identity document: %v", err)
+	}
+	return CloudMetadata{Provider: "aws", Region: document.Region, Zone: document.AvailabilityZone, InstanceID: document.InstanceID}, nil
+}
+
+// GCPDetector reads the zone and instance ID from the Compute Engine metadata server.
+type GCPDetector struct {
+
+	// metadata endpoint, defaults to DefaultGCPMetadataURL
+	BaseURL string
+
+	// HTTP client, defaults to http.DefaultClient
+	Client *http.Client
+
+	// timeout of each request, defaults to DefaultCloudMetadataTimeout
+	Timeout time.Duration
+}
+
+// DetectCloud implements CloudDetector.
+func (detector GCPDetector) DetectCloud(ctx context.Context) (CloudMetadata, error) {
+	baseURL := metadataBaseURL(detector.BaseURL, DefaultGCPMetadataURL)
+	headers := map[string]string{"Metadata-Flavor": "Google"}
+	zone, err := metadataRequest(ctx, detector.Client, detector.Timeout, http.MethodGet, baseURL+"/computeMetadata/v1/instance/zone", headers, "Google")
+	if err != nil {
+		return CloudMetadata{}, err
+	}
+	instanceID, err := metadataRequest(ctx, detector.Client, detector.Timeout, http.MethodGet, baseURL+"/computeMetadata/v1/instance/id", headers, "Google")
+	if err != nil {
+		return CloudMetadata{}, err
+	}
+
+	//The zone comes back as projects/<project number>/zones/<zone>, and the region is the zone without its last part.
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	region := zone
+	if index := strings.LastIndex(zone, "-"); index > 0 {
+		region = zone[:index]
+	}
+	return CloudMetadata{Provider: "gcp", Region: region, Zone: zone, InstanceID: instanceID}, nil
+}
+
+// AzureDetector reads the compute metadata of an Azure virtual machine from the Instance Metadata Service.
+type AzureDetector struct {
+
+	// metadata endpoint, defaults to DefaultAzureMetadataURL
+	BaseURL string
+
+	// HTTP client, defaults to http.DefaultClient
+	Client *http.Client
+
+	// timeout of each request, defaults to DefaultCloudMetadataTimeout
+	Timeout time.Duration
+}
+
+// DetectCloud implements CloudDetector.
+func (detector AzureDetector) DetectCloud(ctx context.Context) (CloudMetadata, error) {
+	baseURL := metadataBaseURL(detector.BaseURL, DefaultAzureMetadataURL)
+	body, err := metadataRequest(ctx, detector.Client, detector.Timeout, http.MethodGet, baseURL+"/metadata/instance/compute?api-version=2021-02-01&format=json",
+		map[string]string{"Metadata": "true"}, "")
+	if err != nil {
+		return CloudMetadata{}, err
+	}
+
+	var compute struct {
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMID     string `json:"vmId"`
+	}
+	if err := json.Unmarshal([]byte(body), &compute); err != nil {
+		return CloudMetadata{}, fmt.Errorf("trakerr: invalid Azure compute metadata: %v", err)
+	}
+	return CloudMetadata{Provider: "azure", Region: compute.Location, Zone: compute.Zone, InstanceID: compute.VMID}, nil
+}
+
+// DetectCloudMetadata queries the detectors concurrently and returns the result of the first one in the list
+// that succeeded. Without detectors, AWS, GCP and Azure are tried at their default endpoints.
+func DetectCloudMetadata(ctx context.Context, detectors ...CloudDetector) (CloudMetadata, error) {
+	if len(detectors) == 0 {
+		detectors = []CloudDetector{AWSDetector{}, GCPDetector{}, AzureDetector{}}
+	}
+
+	type result struct {
+		metadata CloudMetadata
+		err      error
+	}
+	results := make([]chan result, len(detectors))
+	for i, detector := range detectors {
+		results[i] = make(chan result, 1)
+		go func(detector CloudDetector, results chan<- result) {
+			metadata, err := detector.DetectCloud(ctx)
+			results <- result{metadata, err}
+		}(detector, results[i])
+	}
+	for _, results := range results {
+		if result := <-results; result.err == nil {
+			return result.metadata, nil
+		}
+	}
+	return CloudMetadata{}, ErrNoCloudMetadata
+}
+
+// DetectDataCenter runs DetectCloudMetadata and, if an instance was found, uses it as the client's data center
+// and region and adds the zone and instance ID as tags.
+func (trakerrClient *TrakerrClient) DetectDataCenter(ctx context.Context, detectors ...CloudDetector) (CloudMetadata, error) {
+	metadata, err := DetectCloudMetadata(ctx, detectors...)
+	if err != nil {
+		return metadata, err
+	}
+
+	trakerrClient.SetDataCenter(metadata.Provider, metadata.Region)
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	for key, value := range metadata.Tags() {
+		trakerrClient.contextTags[key] = value
+	}
+	return metadata, nil
+}
+
+func metadataBaseURL(baseURL string, defaultURL string) string {
+	if baseURL == "" {
+		baseURL = defaultURL
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// metadataRequest sends a request to a metadata endpoint and returns the trimmed body. When flavor isn't empty
+// the response must carry it in its Metadata-Flavor header, which tells the real GCP server apart from anything
+// else that answers on the same name.
+func metadataRequest(ctx context.Context, client *http.Client, timeout time.Duration, method string, url string,
+	headers map[string]string, flavor string) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if timeout <= 0 {
+		timeout = DefaultCloudMetadataTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("trakerr: %s %s returned %s", method, url, response.Status)
+	}
+	if flavor != "" && response.Header.Get("Metadata-Flavor") != flavor {
+		return "", fmt.Errorf("trakerr: %s is not a %s metadata server", url, flavor)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
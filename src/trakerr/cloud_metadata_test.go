@@ -0,0 +1,138 @@
+package trakerr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newMetadataServer(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestAWSDetectorUsesSessionToken(t *testing.T) {
+	baseURL := newMetadataServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			if r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				http.Error(w, "missing ttl", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("secret-token"))
+		case r.Method == http.MethodGet && r.URL.Path == "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "secret-token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"region":"us-east-1","availabilityZone":"us-east-1a","instanceId":"i-0123"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	metadata, err := AWSDetector{BaseURL: baseURL}.DetectCloud(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CloudMetadata{Provider: "aws", Region: "us-east-1", Zone: "us-east-1a", InstanceID: "i-0123"}
+	if metadata != want {
+		t.Errorf("expected %+v, got %+v", want, metadata)
+	}
+}
+
+func TestGCPDetectorRequiresMetadataFlavor(t *testing.T) {
+	flavor := "Google"
+	baseURL := newMetadataServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing flavor", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", flavor)
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/zone":
+			w.Write([]byte("projects/1234/zones/europe-west1-b"))
+		case "/computeMetadata/v1/instance/id":
+			w.Write([]byte("5678"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	metadata, err := GCPDetector{BaseURL: baseURL}.DetectCloud(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CloudMetadata{Provider: "gcp", Region: "europe-west1", Zone: "europe-west1-b", InstanceID: "5678"}
+	if metadata != want {
+		t.Errorf("expected %+v, got %+v", want, metadata)
+	}
+
+	flavor = ""
+	if _, err := (GCPDetector{BaseURL: baseURL}).DetectCloud(context.Background()); err == nil {
+		t.Error("expected a response without the Metadata-Flavor header to be rejected")
+	}
+}
+
+func TestAzureDetector(t *testing.T) {
+	baseURL := newMetadataServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/instance/compute" || r.Header.Get("Metadata") != "true" || r.URL.Query().Get("api-version") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"location":"westeurope","zone":"2","vmId":"vm-42"}`))
+	})
+
+	metadata, err := AzureDetector{BaseURL: baseURL}.DetectCloud(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CloudMetadata{Provider: "azure", Region: "westeurope", Zone: "2", InstanceID: "vm-42"}
+	if metadata != want {
+		t.Errorf("expected %+v, got %+v", want, metadata)
+	}
+}
+
+func TestDetectCloudMetadataTimesOut(t *testing.T) {
+	slow := newMetadataServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+	})
+
+	start := time.Now()
+	_, err := DetectCloudMetadata(context.Background(), AWSDetector{BaseURL: slow, Timeout: 50 * time.Millisecond},
+		GCPDetector{BaseURL: slow, Timeout: 50 * time.Millisecond})
+	if err != ErrNoCloudMetadata {
+		t.Errorf("expected ErrNoCloudMetadata, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("detection took %v", elapsed)
+	}
+}
+
+func TestDetectDataCenterFillsEvents(t *testing.T) {
+	notFound := newMetadataServer(t, http.NotFound)
+	azure := newMetadataServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"location":"westeurope","zone":"1","vmId":"vm-1"}`))
+	})
+	client, recorder := newTestClient(t)
+
+	if _, err := client.DetectDataCenter(context.Background(), AWSDetector{BaseURL: notFound}, AzureDetector{BaseURL: azure}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SendEvent(client.NewEmptyEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	event := recorder.Events()[0]
+	if event.ContextDataCenter != "azure" || event.ContextDataCenterRegion != "westeurope" {
+		t.Errorf("unexpected data center %q/%q", event.ContextDataCenter, event.ContextDataCenterRegion)
+	}
+	want := []string{"cloud.instance_id:vm-1", "cloud.provider:azure", "cloud.zone:1"}
+	if !reflect.DeepEqual(event.ContextTags, want) {
+		t.Errorf("expected tags %v, got %v", want, event.ContextTags)
+	}
+}
@@ -0,0 +1,247 @@
+package trakerr
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ContainerInfo describes the container and Kubernetes pod the application is running in, if any.
+type ContainerInfo struct {
+
+	// container runtime (eg. docker, containerd, cri-o, podman, lxc)
+	Runtime string
+
+	// full ID of the container
+	ContainerID string
+
+	// Kubernetes pod name
+	PodName string
+
+	// Kubernetes namespace of the pod
+	Namespace string
+
+	// Kubernetes node the pod is scheduled on
+	NodeName string
+}
+
+// InContainer returns true if a container runtime or container ID was detected.
+func (info ContainerInfo) InContainer() bool {
+	return info.Runtime != "" || info.ContainerID != ""
+}
+
+// InKubernetes returns true if the application runs in a Kubernetes pod.
+func (info ContainerInfo) InKubernetes() bool {
+	return info.PodName != "" || info.Namespace != ""
+}
+
+// containerTagKeys are the tag keys ContainerInfo.Tags can return.
+var containerTagKeys = []string{"container.runtime", "container.id", "k8s.pod", "k8s.namespace", "k8s.node"}
+
+// Tags returns the detected values as event tags, skipping the ones that weren't detected.
+func (info ContainerInfo) Tags() map[string]string {
+	tags := make(map[string]string)
+	for i, value := range []string{info.Runtime, info.ContainerID, info.PodName, info.Namespace, info.NodeName} {
+		if value != "" {
+			tags[containerTagKeys[i]] = value
+		}
+	}
+	return tags
+}
+
+// EnvironmentDetector detects the container and Kubernetes environment of the process.
+// Every file is read relative to Root, so detection can be pointed at a fixture directory.
+type EnvironmentDetector struct {
+
+	// filesystem root the proc, run and secrets files are read from, defaults to "/"
+	Root string
+
+	// environment lookup, defaults to os.Getenv
+	Getenv func(key string) string
+}
+
+var (
+	containerInfoOnce sync.Once
+	containerInfo     ContainerInfo
+
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+	// containerRuntimeMarkers maps substrings of cgroup and mount paths to the runtime that creates them.
+	// Order matters: the more specific markers come first.
+	containerRuntimeMarkers = []struct {
+		marker  string
+		runtime string
+	}{
+		{"cri-containerd", "containerd"},
+		{"crio", "cri-o"},
+		{"libpod", "podman"},
+		{"docker", "docker"},
+		{"containerd", "containerd"},
+		{"lxc", "lxc"},
+	}
+
+	// cgroupContainerMarkers are the cgroup path fragments the runtimes nest container cgroups under.
+	cgroupContainerMarkers = []string{"/docker/", "/docker-", "/kubepods", "/libpod", "/lxc/", "/ecs/", "cri-containerd-", "crio-"}
+
+	// mountContainerPatterns match the per container directories of each runtime in mountinfo. Image layer
+	// directories (eg. containers/storage/overlay/<id>) and pod sandbox directories also contain 64 character
+	// IDs, so only these paths are trusted. containers/storage is shared by podman and CRI-O, so the runtime
+	// is left to the marker files.
+	mountContainerPatterns = []struct {
+		pattern *regexp.Regexp
+		runtime string
+	}{
+		{regexp.MustCompile(`/docker/containers/([0-9a-f]{64})/`), "docker"},
+		{regexp.MustCompile(`/overlay-containers/([0-9a-f]{64})/userdata/`), ""},
+		{regexp.MustCompile(`/io\.containerd\.runtime\.v2\.task/k8s\.io/([0-9a-f]{64})/`), "containerd"},
+	}
+
+	podNameVariables   = []string{"POD_NAME", "K8S_POD_NAME", "MY_POD_NAME"}
+	namespaceVariables = []string{"POD_NAMESPACE", "K8S_NAMESPACE", "MY_POD_NAMESPACE"}
+	nodeNameVariables  = []string{"NODE_NAME", "K8S_NODE_NAME", "MY_NODE_NAME"}
+)
+
+// CurrentContainerInfo returns the container and Kubernetes environment of the process.
+// Detection runs once per process against the real filesystem root and the result is cached.
+func CurrentContainerInfo() ContainerInfo {
+	containerInfoOnce.Do(func() {
+		containerInfo = EnvironmentDetector{}.DetectContainer()
+	})
+	return containerInfo
+}
+
+// DetectContainer reads the container runtime and ID from cgroups, mountinfo and the runtime marker files,
+// and the Kubernetes pod from the downward API environment variables and the service account files.
+func (detector EnvironmentDetector) DetectContainer() ContainerInfo {
+	var info ContainerInfo
+
+	info.Runtime, info.ContainerID = detector.scanCgroup()
+	if info.ContainerID == "" {
+		runtime, id := detector.scanMountinfo()
+		info.ContainerID = id
+		if info.Runtime == "" {
+			info.Runtime = runtime
+		}
+	}
+	if info.Runtime == "" {
+		if detector.exists(".dockerenv") {
+			info.Runtime = "docker"
+		} else if detector.exists("run/.containerenv") {
+			info.Runtime = "podman"
+		}
+	}
+
+	inKubernetes := detector.getenv("KUBERNETES_SERVICE_HOST") != ""
+	info.Namespace = detector.firstenv(namespaceVariables)
+	if info.Namespace == "" {
+		info.Namespace = detector.readTrimmed("var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	}
+	if inKubernetes || info.Namespace != "" {
+		info.PodName = detector.firstenv(podNameVariables)
+		if info.PodName == "" {
+			info.PodName = detector.getenv("HOSTNAME")
+		}
+		if info.PodName == "" {
+			info.PodName = detector.readTrimmed("etc/hostname")
+		}
+		info.NodeName = detector.firstenv(nodeNameVariables)
+	}
+	return info
+}
+
+// scanCgroup looks for the container in the cgroup paths of the process, which both cgroup v1 and v2 list
+// in proc/self/cgroup. With a private cgroup namespace on v2 the path is just "/" and nothing is found.
+func (detector EnvironmentDetector) scanCgroup() (string, string) {
+	file, err := os.Open(detector.path("proc/self/cgroup"))
+	if err != nil {
+		return "", ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		id := containerIDPattern.FindString(line)
+		if id == "" {
+			continue
+		}
+		for _, marker := range cgroupContainerMarkers {
+			if strings.Contains(line, marker) {
+				return containerRuntime(line), id
+			}
+		}
+	}
+	return "", ""
+}
+
+// scanMountinfo looks for the per container directory the runtime bind mounts hostname, resolv.conf or
+// the root filesystem from, which mountinfo still shows when the cgroup path is hidden.
+func (detector EnvironmentDetector) scanMountinfo() (string, string) {
+	file, err := os.Open(detector.path("proc/self/mountinfo"))
+	if err != nil {
+		return "", ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, mount := range mountContainerPatterns {
+			if match := mount.pattern.FindStringSubmatch(line); match != nil {
+				return mount.runtime, match[1]
+			}
+		}
+	}
+	return "", ""
+}
+
+// containerRuntime returns the runtime whose marker appears in a cgroup or mount path.
+func containerRuntime(path string) string {
+	for _, marker := range containerRuntimeMarkers {
+		if strings.Contains(path, marker.marker) {
+			return marker.runtime
+		}
+	}
+	return ""
+}
+
+func (detector EnvironmentDetector) path(name string) string {
+	root := detector.Root
+	if root == "" {
+		root = "/"
+	}
+	return filepath.Join(root, filepath.FromSlash(name))
+}
+
+func (detector EnvironmentDetector) exists(name string) bool {
+	_, err := os.Stat(detector.path(name))
+	return err == nil
+}
+
+func (detector EnvironmentDetector) readTrimmed(name string) string {
+	content, err := ioutil.ReadFile(detector.path(name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func (detector EnvironmentDetector) getenv(key string) string {
+	if detector.Getenv != nil {
+		return detector.Getenv(key)
+	}
+	return os.Getenv(key)
+}
+
+func (detector EnvironmentDetector) firstenv(keys []string) string {
+	for _, key := range keys {
+		if value := detector.getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package trakerr
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectContainer(t *testing.T) {
+	id := func(c string) string { return strings.Repeat(c, 64) }
+	downwardAPI := map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"HOSTNAME":                "ignored-hostname",
+		"POD_NAME":                "web-5f7c9-xk2lp",
+		"POD_NAMESPACE":           "shop",
+		"NODE_NAME":               "node-a",
+	}
+
+	for _, test := range []struct {
+		fixture string
+		env     map[string]string
+		want    ContainerInfo
+	}{
+		{"cgroup-v1-docker", nil, ContainerInfo{Runtime: "docker", ContainerID: id("a")}},
+		{"cgroup-v2-docker-scope", nil, ContainerInfo{Runtime: "docker", ContainerID: id("b")}},
+		{"kubepods-containerd", downwardAPI, ContainerInfo{Runtime: "containerd", ContainerID: id("c"),
+			PodName: "web-5f7c9-xk2lp", Namespace: "shop", NodeName: "node-a"}},
+		{"cgroup-v2-mountinfo-docker", nil, ContainerInfo{Runtime: "docker", ContainerID: id("d")}},
+		{"cgroup-v2-mountinfo-podman", nil, ContainerInfo{Runtime: "podman", ContainerID: id("e")}},
+		{"containerd-sandbox-mountinfo", nil, ContainerInfo{Runtime: "containerd", ContainerID: id("f")}},
+		{"dockerenv-only", nil, ContainerInfo{Runtime: "docker"}},
+		{"containerenv-only", nil, ContainerInfo{Runtime: "podman"}},
+		{"kubernetes-serviceaccount", map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"},
+			ContainerInfo{PodName: "checkout-7d9f8-abcde", Namespace: "payments"}},
+		{"missing", downwardAPI, ContainerInfo{PodName: "web-5f7c9-xk2lp", Namespace: "shop", NodeName: "node-a"}},
+		{"missing", nil, ContainerInfo{}},
+	} {
+		env := test.env
+		detector := EnvironmentDetector{
+			Root:   filepath.Join("testdata", "container", test.fixture),
+			Getenv: func(key string) string { return env[key] },
+		}
+		if got := detector.DetectContainer(); got != test.want {
+			t.Errorf("%s: expected %+v, got %+v", test.fixture, test.want, got)
+		}
+	}
+}
+
+func TestContainerInfoTags(t *testing.T) {
+	tags := ContainerInfo{Runtime: "docker", PodName: "web", NodeName: "node-a"}.Tags()
+	want := map[string]string{"container.runtime": "docker", "k8s.pod": "web", "k8s.node": "node-a"}
+	if len(tags) != len(want) {
+		t.Fatalf("expected %v, got %v", want, tags)
+	}
+	for key, value := range want {
+		if tags[key] != value {
+			t.Errorf("tag %s: expected %q, got %q", key, value, tags[key])
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package trakerr
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// SetString sets the string custom data slot CustomData<slot>, slot 1 to 10.
+func (customData *CustomData) SetString(slot int, value string) {
+	checkSlot(slot)
+	reflect.ValueOf(&customData.StringData).Elem().FieldByName("CustomData" + strconv.Itoa(slot)).SetString(value)
+}
+
+// SetDouble sets the double custom data slot CustomData<slot>, slot 1 to 10.
+func (customData *CustomData) SetDouble(slot int, value float64) {
+	checkSlot(slot)
+	reflect.ValueOf(&customData.DoubleData).Elem().FieldByName("CustomData" + strconv.Itoa(slot)).SetFloat(value)
+}
+
+func checkSlot(slot int) {
+	if slot < 1 || slot > 10 {
+		panic("trakerr: custom data slot " + strconv.Itoa(slot) + " is out of range, use 1 to 10")
+	}
+}
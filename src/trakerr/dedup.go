@@ -0,0 +1,251 @@
+package trakerr
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tags added to the aggregated event that reports suppressed duplicates.
+const (
+	DuplicateCountTagKey     = "dedup.count"
+	DuplicateFirstSeenTagKey = "dedup.first_seen"
+	DuplicateLastSeenTagKey  = "dedup.last_seen"
+)
+
+// fingerprintFrames is the number of in-app stack frames that are part of a fingerprint.
+const fingerprintFrames = 3
+
+// ErrDuplicate is returned by SendEvent when the event was suppressed as a duplicate. It is counted in the
+// next aggregated event instead.
+var ErrDuplicate = fmt.Errorf("%w: duplicate", ErrEventDropped)
+
+// trakerrPackage is the import path of this package, whose frames are never in-app.
+var trakerrPackage = reflect.TypeOf(TrakerrClient{}).PkgPath()
+
+// messageVariables match the parts of a message that change between occurrences of the same error.
+var messageVariables = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<hex>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+}
+
+// MessageTemplate replaces the quoted strings, UUIDs, hexadecimal IDs and numbers in message with placeholders,
+// so "user 42 not found" and "user 43 not found" have the same template.
+func MessageTemplate(message string) string {
+	for _, variable := range messageVariables {
+		message = variable.pattern.ReplaceAllString(message, variable.replacement)
+	}
+	return message
+}
+
+// isInAppFrame reports whether function belongs to the application rather than to the Go runtime, the standard
+// library or this package. Standard library import paths have no dot in their first element.
+func isInAppFrame(function string) bool {
+	if strings.HasPrefix(function, trakerrPackage+".") {
+		return false
+	}
+	if strings.HasPrefix(function, "main.") {
+		return true
+	}
+	first := function
+	if index := strings.Index(first, "/"); index >= 0 {
+		first = first[:index]
+	} else if index := strings.Index(first, "."); index >= 0 {
+		first = first[:index]
+	}
+	return strings.Contains(first, ".")
+}
+
+// Fingerprint identifies the events that report the same problem: the event type, the message template and
+// the function and file of the top in-app stack frames. Line numbers are left out so a fingerprint survives
+// unrelated edits to the same file.
+func Fingerprint(appEvent *AppEvent) string {
+	parts := []string{appEvent.EventType, MessageTemplate(appEvent.EventMessage)}
+	frames := 0
+	for _, trace := range appEvent.EventStacktrace {
+		for _, line := range trace.TraceLines {
+			if frames == fingerprintFrames {
+				break
+			}
+			if isInAppFrame(line.Function) {
+				parts = append(parts, line.Function+"@"+line.File)
+				frames++
+			}
+		}
+	}
+	sum := sha1.Sum([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// duplicateEntry tracks one fingerprint: the occurrences suppressed since the last report and the latest of them.
+type duplicateEntry struct {
+	event     *AppEvent
+	count     int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// deduplicator suppresses events whose fingerprint was seen less than window ago. Each occurrence slides the
+// window, and every window the suppressed occurrences are reported as one aggregated event.
+type deduplicator struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]*duplicateEntry
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// suppress records an occurrence of appEvent and reports whether it is a duplicate that shouldn't be sent.
+func (dedup *deduplicator) suppress(appEvent *AppEvent, now time.Time) bool {
+	fingerprint := Fingerprint(appEvent)
+
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+	entry := dedup.entries[fingerprint]
+	if entry == nil {
+		dedup.entries[fingerprint] = &duplicateEntry{firstSeen: now, lastSeen: now}
+		return false
+	}
+	duplicate := now.Sub(entry.lastSeen) < dedup.window
+	entry.lastSeen = now
+	if duplicate {
+		entry.event = appEvent
+		entry.count++
+	}
+	return duplicate
+}
+
+// aggregate returns one event per fingerprint with suppressed occurrences and forgets the fingerprints
+// that weren't seen for a whole window.
+func (dedup *deduplicator) aggregate(now time.Time) []*AppEvent {
+	dedup.mu.Lock()
+	defer dedup.mu.Unlock()
+
+	var events []*AppEvent
+	for fingerprint, entry := range dedup.entries {
+		if entry.count > 0 {
+			event := entry.event.Copy()
+			event.EventTime = entry.lastSeen.UnixNano() / int64(time.Millisecond)
+			event.ContextTags = mergeTags(event.ContextTags, map[string]string{
+				DuplicateCountTagKey:     strconv.Itoa(entry.count),
+				DuplicateFirstSeenTagKey: entry.firstSeen.UTC().Format(time.RFC3339Nano),
+				DuplicateLastSeenTagKey:  entry.lastSeen.UTC().Format(time.RFC3339Nano),
+			})
+			events = append(events, event)
+			entry.event = nil
+			entry.count = 0
+		} else if now.Sub(entry.lastSeen) >= dedup.window {
+			delete(dedup.entries, fingerprint)
+		}
+	}
+	return events
+}
+
+// EnableDeduplication suppresses events with the same Fingerprint sent less than window after the previous one.
+// The first occurrence is sent right away; the duplicates are counted and sent every window as one event
+// carrying the dedup.count, dedup.first_seen and dedup.last_seen tags. A window of 0 turns deduplication off
+// after reporting the pending duplicates. Call Close before the program exits to send the last report.
+func (trakerrClient *TrakerrClient) EnableDeduplication(window time.Duration) {
+	trakerrClient.mu.Lock()
+	previous := trakerrClient.dedup
+	trakerrClient.dedup = nil
+	if window > 0 {
+		trakerrClient.dedup = &deduplicator{
+			window:  window,
+			entries: make(map[string]*duplicateEntry),
+			stop:    make(chan struct{}),
+			done:    make(chan struct{}),
+		}
+		go trakerrClient.reportDuplicates(trakerrClient.dedup)
+	}
+	trakerrClient.mu.Unlock()
+
+	if previous != nil {
+		trakerrClient.stopDeduplicator(previous)
+	}
+}
+
+// reportDuplicates sends the aggregated events of dedup every window until it is stopped.
+func (trakerrClient *TrakerrClient) reportDuplicates(dedup *deduplicator) {
+	defer close(dedup.done)
+	ticker := time.NewTicker(dedup.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			trakerrClient.sendAggregated(dedup)
+		case <-dedup.stop:
+			return
+		}
+	}
+}
+
+// stopDeduplicator stops the reporting goroutine of dedup and sends what it still holds.
+func (trakerrClient *TrakerrClient) stopDeduplicator(dedup *deduplicator) error {
+	close(dedup.stop)
+	<-dedup.done
+	return trakerrClient.sendAggregated(dedup)
+}
+
+// sendAggregated sends the aggregated events of dedup and returns the first error.
+func (trakerrClient *TrakerrClient) sendAggregated(dedup *deduplicator) error {
+	var firstErr error
+	for _, event := range dedup.aggregate(time.Now()) {
+		if _, err := trakerrClient.post(event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Flush sends the aggregated events for the duplicates suppressed so far without waiting for the window to end,
+// and the report of the sessions ended so far when session tracking is enabled.
+func (trakerrClient *TrakerrClient) Flush() error {
+	trakerrClient.mu.RLock()
+	dedup := trakerrClient.dedup
+	sessions := trakerrClient.sessions
+	trakerrClient.mu.RUnlock()
+	var firstErr error
+	if dedup != nil {
+		firstErr = trakerrClient.sendAggregated(dedup)
+	}
+	if sessions != nil {
+		if err := trakerrClient.sendSessionReports(sessions); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close stops the background work of the client and sends the events it still holds.
+// The client can still send events afterwards, but duplicates are no longer suppressed and sessions no longer counted.
+func (trakerrClient *TrakerrClient) Close() error {
+	trakerrClient.mu.Lock()
+	dedup := trakerrClient.dedup
+	sessions := trakerrClient.sessions
+	trakerrClient.dedup = nil
+	trakerrClient.sessions = nil
+	trakerrClient.mu.Unlock()
+	var firstErr error
+	if dedup != nil {
+		firstErr = trakerrClient.stopDeduplicator(dedup)
+	}
+	if sessions != nil {
+		if err := trakerrClient.stopSessionTracking(sessions); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,114 @@
+package trakerr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	for message, want := range map[string]string{
+		"user 42 not found":                                      "user <n> not found",
+		`open "/tmp/a.txt": no such file`:                        "open <str>: no such file",
+		"request 6ba7b810-9dad-11d1-80b4-00c04fd430c8 timed out": "request <uuid> timed out",
+		"bad pointer 0xc000012345 after 1.5s":                    "bad pointer <hex> after <n>s",
+		"object deadbeef01 missing":                              "object <hex> missing",
+	} {
+		if got := MessageTemplate(message); got != want {
+			t.Errorf("MessageTemplate(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
+
+func TestFingerprintUsesInAppFrames(t *testing.T) {
+	event := func(message string, functions ...string) *AppEvent {
+		trace := InnerStackTrace{}
+		for i, function := range functions {
+			trace.TraceLines = append(trace.TraceLines, StackTraceLine{Function: function, File: function + ".go", Line: int32(i)})
+		}
+		return &AppEvent{EventType: "*errors.errorString", EventMessage: message, EventStacktrace: []InnerStackTrace{trace}}
+	}
+
+	a := event("user 1 not found", "runtime.gopanic", trakerrPackage+".(*TrakerrClient).Recover", "example.com/app.load", "main.main")
+	b := event("user 2 not found", "runtime.gopanic", "net/http.(*conn).serve", "example.com/app.load", "main.main")
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Error("expected events that only differ in variables and library frames to share a fingerprint")
+	}
+	if Fingerprint(a) == Fingerprint(event("user 1 not found", "example.com/app.save", "main.main")) {
+		t.Error("expected a different in-app frame to change the fingerprint")
+	}
+	if Fingerprint(a) == Fingerprint(event("user 1 deleted", "example.com/app.load", "main.main")) {
+		t.Error("expected a different message template to change the fingerprint")
+	}
+}
+
+func TestDeduplicationAggregatesRepeatedErrors(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.EnableDeduplication(time.Hour)
+	defer client.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err := client.SendEvent(client.CreateAppEventFromError("error", "", errors.New("connection reset")))
+		if i == 0 && err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("expected occurrence %d to be suppressed, got %v", i, err)
+		}
+	}
+	if _, err := client.SendEvent(client.CreateAppEventFromError("error", "", errors.New("disk full"))); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(recorder.Events()); got != 2 {
+		t.Fatalf("expected the first occurrence of each error to be sent, got %d events", got)
+	}
+
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	events := recorder.Events()
+	if len(events) != 3 {
+		t.Fatalf("expected one aggregated event, got %d events", len(events))
+	}
+	aggregated := events[2]
+	if aggregated.EventMessage != "connection reset" {
+		t.Errorf("unexpected aggregated event %+v", aggregated)
+	}
+	tags := strings.Join(aggregated.ContextTags, ",")
+	for _, prefix := range []string{"dedup.count:4", "dedup.first_seen:", "dedup.last_seen:"} {
+		if !strings.Contains(tags, prefix) {
+			t.Errorf("expected tag %s in %v", prefix, aggregated.ContextTags)
+		}
+	}
+
+	if err := client.Flush(); err != nil || len(recorder.Events()) != 3 {
+		t.Errorf("expected nothing to report after a flush, got %d events (%v)", len(recorder.Events()), err)
+	}
+}
+
+func TestDeduplicatorWindowSlides(t *testing.T) {
+	dedup := &deduplicator{window: time.Minute, entries: make(map[string]*duplicateEntry)}
+	event := &AppEvent{EventType: "T", EventMessage: "m"}
+	start := time.Unix(0, 0)
+
+	if dedup.suppress(event, start) {
+		t.Fatal("the first occurrence must be sent")
+	}
+	if !dedup.suppress(event, start.Add(50*time.Second)) || !dedup.suppress(event, start.Add(100*time.Second)) {
+		t.Fatal("occurrences less than a window apart must be suppressed")
+	}
+	if dedup.suppress(event, start.Add(200*time.Second)) {
+		t.Fatal("an occurrence after a quiet window must be sent")
+	}
+
+	events := dedup.aggregate(start.Add(200 * time.Second))
+	if len(events) != 1 || !reflect.DeepEqual(events[0].ContextTags, []string{
+		"dedup.count:2", "dedup.first_seen:1970-01-01T00:00:00Z", "dedup.last_seen:1970-01-01T00:03:20Z"}) {
+		t.Errorf("unexpected aggregated events %+v", events)
+	}
+	if events := dedup.aggregate(start.Add(time.Hour)); len(events) != 0 || len(dedup.entries) != 0 {
+		t.Errorf("expected the expired fingerprint to be forgotten, got %d events and %d entries", len(events), len(dedup.entries))
+	}
+}
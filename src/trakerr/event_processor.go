@@ -0,0 +1,73 @@
+package trakerr
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrDroppedByProcessor is returned by SendEvent when an EventProcessor dropped the event.
+var ErrDroppedByProcessor = fmt.Errorf("%w: dropped by event processor", ErrEventDropped)
+
+// Hint carries what an event was created from, for EventProcessors that need more than the event.
+type Hint struct {
+
+	// the error the event was created from, nil for events that weren't created from an error
+	Error error
+
+	// the value passed to panic, for events sent by the Recover and Notify functions
+	Panic interface{}
+}
+
+// newHint returns the hint for an event created from err. panicked is true when err was recovered from a panic.
+func newHint(err interface{}, panicked bool) Hint {
+	hint := Hint{}
+	hint.Error, _ = err.(error)
+	if panicked {
+		hint.Panic = err
+	}
+	return hint
+}
+
+// EventProcessor inspects, enriches or replaces an event before it is sent. Returning nil drops the event.
+// Processors are called on a copy of the event the caller passed in, so they may change it.
+type EventProcessor func(appEvent *AppEvent, hint Hint) *AppEvent
+
+// AddEventProcessor adds a processor that every event goes through before it is sent, after the processors
+// added before it. Client processors run before the ones of the Scope carried by the context.
+func (trakerrClient *TrakerrClient) AddEventProcessor(processor EventProcessor) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.processors = append(trakerrClient.processors[:len(trakerrClient.processors):len(trakerrClient.processors)], processor)
+}
+
+// AddEventProcessor adds a processor for the events sent with this scope. It runs after the client processors.
+func (scope *Scope) AddEventProcessor(processor EventProcessor) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.processors = append(scope.processors[:len(scope.processors):len(scope.processors)], processor)
+}
+
+// EventProcessors returns the processors added to the scope, in order.
+func (scope *Scope) EventProcessors() []EventProcessor {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+	return scope.processors
+}
+
+// processEvent runs appEvent through the client processors and then those of the Scope carried by ctx.
+// It returns nil if one of them dropped the event.
+func (trakerrClient *TrakerrClient) processEvent(ctx context.Context, appEvent *AppEvent, hint Hint) *AppEvent {
+	trakerrClient.mu.RLock()
+	processors := trakerrClient.processors
+	trakerrClient.mu.RUnlock()
+	if scope := ScopeFromContext(ctx); scope != nil {
+		processors = append(processors[:len(processors):len(processors)], scope.EventProcessors()...)
+	}
+
+	for _, processor := range processors {
+		if appEvent = processor(appEvent, hint); appEvent == nil {
+			return nil
+		}
+	}
+	return appEvent
+}
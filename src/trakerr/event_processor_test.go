@@ -0,0 +1,95 @@
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEventProcessorsRunInOrder(t *testing.T) {
+	client, recorder := newTestClient(t)
+	var calls []string
+	client.AddEventProcessor(func(appEvent *AppEvent, hint Hint) *AppEvent {
+		calls = append(calls, "client 1")
+		appEvent.EventUser = "enriched"
+		return appEvent
+	})
+	client.AddEventProcessor(func(appEvent *AppEvent, hint Hint) *AppEvent {
+		calls = append(calls, "client 2")
+		return appEvent
+	})
+	ctx, scope := WithScope(context.Background())
+	scope.AddEventProcessor(func(appEvent *AppEvent, hint Hint) *AppEvent {
+		calls = append(calls, "scope")
+		replaced := appEvent.Copy()
+		replaced.EventMessage = "replaced"
+		return replaced
+	})
+
+	template := client.NewAppEvent("info", "", "Type", "message")
+	if _, err := client.SendEventContext(ctx, template); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(calls, []string{"client 1", "client 2", "scope"}) {
+		t.Errorf("unexpected processor order %v", calls)
+	}
+	sent := recorder.Events()[0]
+	if sent.EventUser != "enriched" || sent.EventMessage != "replaced" {
+		t.Errorf("processor changes missing from %+v", sent)
+	}
+	if template.EventUser != "" || template.EventMessage != "message" {
+		t.Errorf("processors changed the caller's event %+v", template)
+	}
+
+	calls = nil
+	if _, err := client.SendEvent(template); err != nil || len(calls) != 2 {
+		t.Errorf("expected only the client processors without a scope, got %v (%v)", calls, err)
+	}
+}
+
+func TestEventProcessorCanDropEvents(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.AddEventProcessor(func(appEvent *AppEvent, hint Hint) *AppEvent {
+		if errors.Is(hint.Error, context.Canceled) {
+			return nil
+		}
+		return appEvent
+	})
+
+	_, err := client.SendErrorContext(context.Background(), "error", "", context.Canceled)
+	if !errors.Is(err, ErrDroppedByProcessor) || !errors.Is(err, ErrEventDropped) {
+		t.Errorf("expected ErrDroppedByProcessor, got %v", err)
+	}
+	if _, err := client.SendErrorContext(context.Background(), "error", "", errors.New("kept")); err != nil {
+		t.Fatal(err)
+	}
+	if events := recorder.Events(); len(events) != 1 || events[0].EventMessage != "kept" {
+		t.Errorf("expected only the kept error, got %+v", events)
+	}
+}
+
+func TestEventProcessorHintCarriesPanic(t *testing.T) {
+	client, _ := newTestClient(t)
+	boom := errors.New("boom")
+	var hints []Hint
+	client.AddEventProcessor(func(appEvent *AppEvent, hint Hint) *AppEvent {
+		hints = append(hints, hint)
+		return nil
+	})
+
+	func() {
+		defer client.Recover("error", "")
+		panic(boom)
+	}()
+	func() {
+		defer client.RecoverWithAppEvent(client.NewEmptyEvent())
+		panic("text")
+	}()
+	client.SendError("error", "", boom)
+
+	want := []Hint{{Error: boom, Panic: boom}, {Panic: "text"}, {Error: boom}}
+	if !reflect.DeepEqual(hints, want) {
+		t.Errorf("expected hints %+v, got %+v", want, hints)
+	}
+}
@@ -42,36 +42,55 @@ func (tb *EventTraceBuilder) AddStackTrace(traces []InnerStackTrace, err interfa
 //GetTraceLines parses each line of the stacktrace and returns an array of lines to populate InnerStackTrace
 func (tb *EventTraceBuilder) GetTraceLines(err interface{}, depth int, skip int) []StackTraceLine {
 	var traceLines = []StackTraceLine{}
-	var goPath = tb.FileErrorHandler(filepath.Abs(os.Getenv("GOPATH")))
-	var goRuntime = tb.FileErrorHandler(filepath.Abs(runtime.GOROOT()))
 	for i := 0; i < depth; i++ {
 		pc, file, line, ok := runtime.Caller(skip + 1 + i)
 		if !ok {
 			break
 		}
-		var localFilePath = tb.FileErrorHandler(filepath.Abs(file))
-
 		var function = runtime.FuncForPC(pc)
-		stLine := StackTraceLine{}
-
-		var finalstring string
-		if strings.Contains(strings.ToLower(localFilePath), strings.ToLower(goPath)) { //If it's goPath stacktrace
-			finalstring = localFilePath[len(goPath):]
-		} else if strings.Contains(strings.ToLower(localFilePath), strings.ToLower(goRuntime)) { //Otherwise its called from the runtime.
-			finalstring = localFilePath[len(goRuntime):]
-		} else {
-			finalstring = localFilePath
-		}
+		traceLines = append(traceLines, tb.newTraceLine(function.Name(), file, line))
+	}
 
-		stLine.File = strings.TrimLeft(finalstring, "\\/ ")
-		stLine.Line = int32(line)
-		stLine.Function = function.Name()
-		traceLines = append(traceLines, stLine)
+	return traceLines
+}
+
+//GetTraceLinesFromPCs parses the program counters returned by runtime.Callers into at most depth lines,
+//for stacks that were captured before the event is created, such as the call site of a log record.
+func (tb *EventTraceBuilder) GetTraceLinesFromPCs(pcs []uintptr, depth int) []StackTraceLine {
+	var traceLines = []StackTraceLine{}
+	var frames = runtime.CallersFrames(pcs)
+	for len(traceLines) < depth {
+		frame, more := frames.Next()
+		if frame.PC == 0 {
+			break
+		}
+		traceLines = append(traceLines, tb.newTraceLine(frame.Function, frame.File, frame.Line))
+		if !more {
+			break
+		}
 	}
 
 	return traceLines
 }
 
+//newTraceLine returns the trace line of a frame, with the file relative to the GOPATH or GOROOT it is in.
+func (tb *EventTraceBuilder) newTraceLine(function string, file string, line int) StackTraceLine {
+	var goPath = tb.FileErrorHandler(filepath.Abs(os.Getenv("GOPATH")))
+	var goRuntime = tb.FileErrorHandler(filepath.Abs(runtime.GOROOT()))
+	var localFilePath = tb.FileErrorHandler(filepath.Abs(file))
+
+	var finalstring string
+	if strings.Contains(strings.ToLower(localFilePath), strings.ToLower(goPath)) { //If it's goPath stacktrace
+		finalstring = localFilePath[len(goPath):]
+	} else if strings.Contains(strings.ToLower(localFilePath), strings.ToLower(goRuntime)) { //Otherwise its called from the runtime.
+		finalstring = localFilePath[len(goRuntime):]
+	} else {
+		finalstring = localFilePath
+	}
+
+	return StackTraceLine{Function: function, File: strings.TrimLeft(finalstring, "\\/ "), Line: int32(line)}
+}
+
 //FileErrorHandler is a small error handler for calls to find the paths of the files for path output parsing.
 func (tb *EventTraceBuilder) FileErrorHandler(str string, er error) string {
 	if er != nil {
@@ -0,0 +1,269 @@
+package trakerr
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tag keys of the request context added by HTTPMiddleware.
+const (
+	HTTPMethodTagKey     = "http.method"
+	HTTPRouteTagKey      = "http.route"
+	HTTPStatusCodeTagKey = "http.status_code"
+	HTTPRemoteIPTagKey   = "http.remote_ip"
+)
+
+// HTTPMiddlewareOptions configures HTTPMiddlewareWithOptions. Zero values use the defaults.
+type HTTPMiddlewareOptions struct {
+
+	// also report responses with a 5xx status code written by the handler without panicking
+	ReportServerErrors bool
+
+	// written after a recovered panic if the handler hadn't written a status code yet, defaults to a plain
+	// 500 Internal Server Error
+	ErrorResponse http.Handler
+
+	// returns the route template of the request, eg. "/users/{id}"; defaults to the pattern of the
+	// http.ServeMux that matched the request (Go 1.23 and later) and then the URL path
+	Route func(r *http.Request) string
+
+	// scrubs the query values of the reported URL, defaults to NewScrubber(); the values of its DenyKeys are redacted
+	Scrubber *Scrubber
+
+	// take the remote IP from the first X-Forwarded-For address; only enable behind a proxy that sets the header
+	TrustForwardedFor bool
+
+	// log level of recovered panics, defaults to "error"
+	LogLevel string
+
+	// Classification of the events, defaults to the client default
+	Classification string
+}
+
+// HTTPMiddleware returns a handler that serves requests with next, recovers the panics it raises and sends them
+// to Trakerr with the request method, route, URL, status code, latency and remote IP. http.ErrAbortHandler is
+// panicked again without being reported, so net/http can abort the response as usual.
+// Every request runs in a transaction, which continues the trace of the traceparent header when there is one.
+//
+//	http.ListenAndServe(":8080", client.HTTPMiddleware(mux))
+func (trakerrClient *TrakerrClient) HTTPMiddleware(next http.Handler) http.Handler {
+	return trakerrClient.HTTPMiddlewareWithOptions(next, nil)
+}
+
+// HTTPMiddlewareWithOptions is HTTPMiddleware with options, which may be nil.
+func (trakerrClient *TrakerrClient) HTTPMiddlewareWithOptions(next http.Handler, options *HTTPMiddlewareOptions) http.Handler {
+	middleware := &httpMiddleware{client: trakerrClient, next: next}
+	if options != nil {
+		middleware.options = *options
+	}
+	if middleware.options.ErrorResponse == nil {
+		middleware.options.ErrorResponse = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		})
+	}
+	if middleware.options.Scrubber == nil {
+		middleware.options.Scrubber = NewScrubber()
+	}
+	if middleware.options.LogLevel == "" {
+		middleware.options.LogLevel = "error"
+	}
+	return middleware
+}
+
+type httpMiddleware struct {
+	client  *TrakerrClient
+	next    http.Handler
+	options HTTPMiddlewareOptions
+}
+
+func (middleware *httpMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	ctx, _ := WithScope(r.Context())
+	var transaction *Span
+	if traceContext, ok := ExtractTraceContext(r.Header); ok {
+		ctx, transaction = middleware.client.ContinueTransaction(ctx, traceContext, r.Method+" "+r.URL.Path)
+	} else {
+		ctx, transaction = middleware.client.StartTransaction(ctx, r.Method+" "+r.URL.Path)
+	}
+	r = r.WithContext(ctx)
+	recorder := &statusRecorder{ResponseWriter: w}
+
+	defer func() {
+		err := recover()
+		// the route is known once the handler has run
+		transaction.SetName(r.Method + " " + middleware.route(r))
+		defer transaction.Finish()
+		if err == nil {
+			if recorder.Status() >= 500 {
+				transaction.SetStatus(SpanStatusError)
+				if middleware.options.ReportServerErrors {
+					middleware.reportServerError(ctx, r, recorder.Status(), time.Since(start))
+				}
+			}
+			return
+		}
+		if err == http.ErrAbortHandler {
+			transaction.SetStatus(SpanStatusError)
+			panic(err)
+		}
+
+		status := http.StatusInternalServerError
+		if recorder.status != 0 {
+			status = recorder.status
+		}
+		appEvent := middleware.client.createAppEventFromErrorWithSkipContext(ctx, err, middleware.options.LogLevel, middleware.options.Classification, 2)
+		middleware.addRequest(appEvent, r, status, time.Since(start))
+		middleware.client.sendContext(ctx, appEvent, newHint(err, true))
+
+		if recorder.status == 0 {
+			middleware.options.ErrorResponse.ServeHTTP(recorder, r)
+		}
+	}()
+
+	middleware.next.ServeHTTP(recorder, r)
+}
+
+// reportServerError sends a 5xx response the handler wrote without panicking.
+func (middleware *httpMiddleware) reportServerError(ctx context.Context, r *http.Request, status int, latency time.Duration) {
+	message := fmt.Sprintf("%s %s returned %d %s", r.Method, middleware.route(r), status, http.StatusText(status))
+	appEvent := middleware.client.NewAppEvent("error", middleware.options.Classification, "HTTP "+strconv.Itoa(status), message)
+	middleware.addRequest(appEvent, r, status, latency)
+	middleware.client.sendContext(ctx, appEvent, Hint{})
+}
+
+// addRequest adds the request context to appEvent.
+func (middleware *httpMiddleware) addRequest(appEvent *AppEvent, r *http.Request, status int, latency time.Duration) {
+	appEvent.ContextURL = scrubURL(requestURL(r), middleware.options.Scrubber)
+	appEvent.ContextOperationTimeMillis = int64(latency / time.Millisecond)
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, map[string]string{
+		HTTPMethodTagKey:     r.Method,
+		HTTPRouteTagKey:      middleware.route(r),
+		HTTPStatusCodeTagKey: strconv.Itoa(status),
+		HTTPRemoteIPTagKey:   remoteIP(r, middleware.options.TrustForwardedFor),
+	})
+}
+
+func (middleware *httpMiddleware) route(r *http.Request) string {
+	if middleware.options.Route != nil {
+		if route := middleware.options.Route(r); route != "" {
+			return route
+		}
+	}
+	if pattern := requestPattern(r); pattern != "" {
+		// a ServeMux pattern may start with the method and host, eg. "GET example.com/users/{id}"
+		if slash := strings.Index(pattern, "/"); slash >= 0 {
+			return pattern[slash:]
+		}
+		return pattern
+	}
+	return r.URL.Path
+}
+
+// requestURL returns the absolute URL of a request received by a server.
+func requestURL(r *http.Request) *url.URL {
+	u := *r.URL
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	return &u
+}
+
+// scrubURL returns u without its password and with the query values scrubbed. The values of denied keys are
+// redacted entirely.
+func scrubURL(u *url.URL, scrubber *Scrubber) string {
+	scrubber.init()
+	scrubbed := *u
+	if password, ok := u.User.Password(); ok && password != "" {
+		scrubbed.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key, values := range query {
+			for i, value := range values {
+				if scrubber.denied[strings.ToLower(key)] {
+					values[i] = Redacted
+				} else {
+					values[i] = scrubber.ScrubString(value)
+				}
+			}
+		}
+		scrubbed.RawQuery = query.Encode()
+	}
+	return scrubbed.String()
+}
+
+// remoteIP returns the IP address of the client that sent r.
+func remoteIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.SplitN(forwarded, ",", 2)[0])
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// statusRecorder is the http.ResponseWriter passed to the wrapped handler, recording the status code it writes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// Status returns the status code written, 200 if the handler wrote a body without one and 0 if it wrote nothing.
+func (recorder *statusRecorder) Status() int {
+	return recorder.status
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 && status >= 200 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Flush implements http.Flusher when the underlying ResponseWriter does.
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker when the underlying ResponseWriter does.
+func (recorder *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := recorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("trakerr: the ResponseWriter does not implement http.Hijacker")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
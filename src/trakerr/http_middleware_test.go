@@ -0,0 +1,131 @@
+package trakerr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func hasTag(event AppEvent, tag string) bool {
+	for _, existing := range event.ContextTags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHTTPMiddlewareRecoversPanics(t *testing.T) {
+	client, recorder := newTestClient(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", panickingHandler)
+	handler := client.HTTPMiddleware(mux)
+
+	request := httptest.NewRequest("POST", "http://example.com/users/42?token=abc&q=john@trakerr.io&page=2", nil)
+	request.RemoteAddr = "10.1.2.3:5678"
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if response.Code != http.StatusInternalServerError || strings.TrimSpace(response.Body.String()) != "Internal Server Error" {
+		t.Errorf("unexpected response %d %q", response.Code, response.Body.String())
+	}
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventMessage != "boom" || event.LogLevel != "error" {
+		t.Errorf("unexpected event %q %q", event.EventMessage, event.LogLevel)
+	}
+	if top := event.EventStacktrace[0].TraceLines[0]; !strings.HasSuffix(top.Function, "panickingHandler") {
+		t.Errorf("stack trace starts at %s, want panickingHandler", top.Function)
+	}
+	if event.ContextURL != "http://example.com/users/42?page=2&q=%5BREDACTED%5D&token=%5BREDACTED%5D" {
+		t.Errorf("unexpected URL %s", event.ContextURL)
+	}
+	// the ServeMux only records its pattern with the Go 1.22 routing enabled, otherwise the path is the route
+	if !hasTag(event, "http.route:/users/") && !hasTag(event, "http.route:/users/42") {
+		t.Errorf("route tag missing from %v", event.ContextTags)
+	}
+	for _, tag := range []string{"http.method:POST", "http.status_code:500", "http.remote_ip:10.1.2.3"} {
+		if !hasTag(event, tag) {
+			t.Errorf("tag %s missing from %v", tag, event.ContextTags)
+		}
+	}
+}
+
+func TestHTTPMiddlewareRepanicsAbortHandler(t *testing.T) {
+	client, recorder := newTestClient(t)
+	handler := client.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	func() {
+		defer func() {
+			if err := recover(); err != http.ErrAbortHandler {
+				t.Errorf("expected http.ErrAbortHandler, got %v", err)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	if len(recorder.Events()) != 0 {
+		t.Error("http.ErrAbortHandler was reported")
+	}
+}
+
+func TestHTTPMiddlewareReportsServerErrors(t *testing.T) {
+	client, recorder := newTestClient(t)
+	unavailable := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			w.Write([]byte("ok"))
+			return
+		}
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	})
+
+	client.HTTPMiddleware(unavailable).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/down", nil))
+	if len(recorder.Events()) != 0 {
+		t.Fatal("5xx response reported without ReportServerErrors")
+	}
+
+	handler := client.HTTPMiddlewareWithOptions(unavailable, &HTTPMiddlewareOptions{ReportServerErrors: true, Route: func(r *http.Request) string { return "/status" }})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/down", nil))
+	if response.Code != http.StatusServiceUnavailable {
+		t.Errorf("response changed to %d", response.Code)
+	}
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].EventType != "HTTP 503" || events[0].EventMessage != "GET /status returned 503 Service Unavailable" || !hasTag(events[0], "http.status_code:503") {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+}
+
+func TestHTTPMiddlewareErrorResponse(t *testing.T) {
+	client, recorder := newTestClient(t)
+	handler := client.HTTPMiddlewareWithOptions(http.HandlerFunc(panickingHandler), &HTTPMiddlewareOptions{
+		ErrorResponse: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+		TrustForwardedFor: true,
+	})
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if response.Code != http.StatusTeapot {
+		t.Errorf("expected the configured error response, got %d", response.Code)
+	}
+	if events := recorder.Events(); len(events) != 1 || !hasTag(events[0], "http.remote_ip:203.0.113.7") {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
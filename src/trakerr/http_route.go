@@ -0,0 +1,11 @@
+//go:build !go1.23
+// +build !go1.23
+
+package trakerr
+
+import "net/http"
+
+// requestPattern returns "": before Go 1.23 the request doesn't carry the http.ServeMux pattern.
+func requestPattern(r *http.Request) string {
+	return ""
+}
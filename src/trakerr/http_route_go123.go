@@ -0,0 +1,11 @@
+//go:build go1.23
+// +build go1.23
+
+package trakerr
+
+import "net/http"
+
+// requestPattern returns the pattern of the http.ServeMux route that matched r.
+func requestPattern(r *http.Request) string {
+	return r.Pattern
+}
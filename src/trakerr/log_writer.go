@@ -0,0 +1,152 @@
+package trakerr
+
+import (
+	"io"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogWriter is an io.Writer for log.SetOutput and log.New that sends every log line to Trakerr.
+// Each Write is sent before it returns, so the line written by log.Fatal reaches Trakerr before the program exits.
+type LogWriter struct {
+	client *TrakerrClient
+	level  string
+
+	// the logger whose flags and prefix the lines are parsed with, defaults to the standard logger
+	Logger *log.Logger
+
+	// if set, lines are also written to it, eg. os.Stderr
+	Next io.Writer
+
+	// EventType of the events, defaults to "log"
+	EventType string
+
+	// Classification of the events, defaults to the client default
+	Classification string
+}
+
+// LogWriter returns a LogWriter sending log lines as events with the given log level. Lines written by
+// log.Fatal, log.Fatalf and log.Fatalln are sent as "fatal".
+//
+//	log.SetOutput(client.LogWriter("error"))
+func (trakerrClient *TrakerrClient) LogWriter(loglevel string) *LogWriter {
+	return &LogWriter{client: trakerrClient, level: loglevel, EventType: "log"}
+}
+
+// Write implements io.Writer. It always reports the whole line as written, so a Trakerr failure doesn't stop
+// the program from logging.
+func (writer *LogWriter) Write(line []byte) (int, error) {
+	if writer.Next != nil {
+		if n, err := writer.Next.Write(line); err != nil {
+			return n, err
+		}
+	}
+
+	logger := writer.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	entry := parseLogLine(strings.TrimSuffix(string(line), "\n"), logger.Flags(), logger.Prefix())
+
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	traceLines := writer.client.eventTraceBuilder.GetTraceLinesFromPCs(pcs, 64)
+	loglevel := writer.level
+	for len(traceLines) > 0 && isLoggingFrame(traceLines[0].Function) {
+		if isFatalFrame(traceLines[0].Function) {
+			loglevel = "fatal"
+		}
+		traceLines = traceLines[1:]
+	}
+	if len(traceLines) == 0 && entry.file != "" {
+		traceLines = []StackTraceLine{{File: entry.file, Line: int32(entry.line)}}
+	}
+	if len(traceLines) > 50 {
+		traceLines = traceLines[:50]
+	}
+
+	appEvent := writer.client.NewAppEvent(loglevel, writer.Classification, writer.EventType, entry.message)
+	appEvent.EventStacktrace = []InnerStackTrace{{Type_: writer.EventType, Message: entry.message, TraceLines: traceLines}}
+	if !entry.time.IsZero() {
+		appEvent.EventTime = entry.time.UnixNano() / int64(time.Millisecond)
+	}
+	writer.client.SendEvent(appEvent)
+	if loglevel == "fatal" {
+		writer.client.Flush()
+	}
+	return len(line), nil
+}
+
+// isLoggingFrame reports whether function belongs to the log package or the LogWriter, which sit between
+// the log call and Write.
+func isLoggingFrame(function string) bool {
+	return strings.HasPrefix(function, "log.") || strings.HasPrefix(function, trakerrPackage+".(*LogWriter).")
+}
+
+func isFatalFrame(function string) bool {
+	return strings.HasPrefix(function, "log.Fatal") || strings.HasPrefix(function, "log.(*Logger).Fatal")
+}
+
+// logEntry is a line written by a log.Logger, split into its parts.
+type logEntry struct {
+	time    time.Time
+	file    string
+	line    int
+	message string
+}
+
+// parseLogLine splits a line written by a log.Logger with the given flags and prefix into the time, the
+// file and line of the call and the message. Parts that don't match the flags are left in the message.
+func parseLogLine(text string, flags int, prefix string) logEntry {
+	entry := logEntry{}
+	if flags&log.Lmsgprefix == 0 {
+		text = strings.TrimPrefix(text, prefix)
+	}
+
+	location := time.Local
+	if flags&log.LUTC != 0 {
+		location = time.UTC
+	}
+	layout := ""
+	if flags&log.Ldate != 0 {
+		layout = "2006/01/02 "
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		layout += "15:04:05"
+		if flags&log.Lmicroseconds != 0 {
+			layout += ".000000"
+		}
+		layout += " "
+	}
+	if layout != "" && len(text) >= len(layout) {
+		if parsed, err := time.ParseInLocation(layout, text[:len(layout)], location); err == nil {
+			if flags&log.Ldate == 0 {
+				now := time.Now().In(location)
+				parsed = time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(), location)
+			}
+			entry.time = parsed
+			text = text[len(layout):]
+		}
+	}
+
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		if end := strings.Index(text, ": "); end > 0 {
+			if colon := strings.LastIndex(text[:end], ":"); colon > 0 {
+				if line, err := strconv.Atoi(text[colon+1 : end]); err == nil {
+					entry.file = text[:colon]
+					entry.line = line
+					text = text[end+2:]
+				}
+			}
+		}
+	}
+
+	if flags&log.Lmsgprefix != 0 {
+		text = strings.TrimPrefix(text, prefix)
+	}
+	entry.message = text
+	return entry
+}
@@ -0,0 +1,81 @@
+package trakerr
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogLine(t *testing.T) {
+	for _, test := range []struct {
+		line   string
+		flags  int
+		prefix string
+		want   logEntry
+	}{
+		{"plain message", 0, "", logEntry{message: "plain message"}},
+		{"app: 2024/05/01 10:20:30 main.go:42: disk full", log.LstdFlags | log.Lshortfile | log.LUTC, "app: ",
+			logEntry{time: time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC), file: "main.go", line: 42, message: "disk full"}},
+		{"2024/05/01 10:20:30.123456 /src/app/main.go:7: app: x: y", log.Ldate | log.Lmicroseconds | log.Llongfile | log.Lmsgprefix | log.LUTC, "app: ",
+			logEntry{time: time.Date(2024, 5, 1, 10, 20, 30, 123456000, time.UTC), file: "/src/app/main.go", line: 7, message: "x: y"}},
+		{"not a date message", log.Ldate, "", logEntry{message: "not a date message"}},
+	} {
+		got := parseLogLine(test.line, test.flags, test.prefix)
+		if !got.time.Equal(test.want.time) || got.file != test.want.file || got.line != test.want.line || got.message != test.want.message {
+			t.Errorf("parseLogLine(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestLogWriterSendsCallSite(t *testing.T) {
+	client, recorder := newTestClient(t)
+	var output bytes.Buffer
+	writer := client.LogWriter("warning")
+	writer.Next = &output
+	logger := log.New(writer, "billing: ", log.LstdFlags|log.Lshortfile)
+	writer.Logger = logger
+
+	logger.Printf("retrying charge %d", 3)
+
+	if !strings.HasSuffix(output.String(), "retrying charge 3\n") {
+		t.Errorf("line wasn't passed on: %q", output.String())
+	}
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.LogLevel != "warning" || event.EventType != "log" || event.EventMessage != "retrying charge 3" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	top := event.EventStacktrace[0].TraceLines[0]
+	if !strings.HasSuffix(top.Function, "TestLogWriterSendsCallSite") || !strings.HasSuffix(top.File, "log_writer_test.go") {
+		t.Errorf("expected the stack trace to start at the log call, got %+v", top)
+	}
+}
+
+func TestLogWriterSendsFatalBeforeExit(t *testing.T) {
+	if url := os.Getenv("TRAKERR_LOG_WRITER_URL"); url != "" {
+		client := NewTrakerrClient("test-api-key", "1.0", "test")
+		client.eventsAPI = *NewEventsApiWithBasePath(url)
+		log.SetOutput(client.LogWriter("error"))
+		log.Fatalf("cannot start: %s", "port in use")
+	}
+
+	_, recorder := newTestClient(t)
+	server := newMetadataServer(t, recorder.ServeHTTP)
+	command := exec.Command(os.Args[0], "-test.run=^TestLogWriterSendsFatalBeforeExit$")
+	command.Env = append(os.Environ(), "TRAKERR_LOG_WRITER_URL="+server)
+	if err := command.Run(); err == nil {
+		t.Fatal("expected log.Fatal to exit with an error")
+	}
+
+	events := recorder.Events()
+	if len(events) != 1 || events[0].LogLevel != "fatal" || events[0].EventMessage != "cannot start: port in use" {
+		t.Errorf("expected the fatal line to be sent before the exit, got %+v", events)
+	}
+}
@@ -0,0 +1,107 @@
+package trakerr
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// OSInfo describes the operating system the application is running on.
+type OSInfo struct {
+
+	// OS or distribution name (eg. Ubuntu, macOS, Windows 10 Pro)
+	Name string
+
+	// OS or distribution version (eg. 22.04, 14.1, 10.0.19045)
+	Version string
+
+	// machine readable distribution ID from os-release (eg. ubuntu, alpine), empty elsewhere
+	ID string
+
+	// kernel name (eg. Linux, Darwin)
+	KernelName string
+
+	// kernel release (eg. 5.15.0-91-generic)
+	KernelRelease string
+}
+
+var (
+	osInfoOnce sync.Once
+	osInfo     OSInfo
+)
+
+// CurrentOSInfo returns the operating system the process is running on.
+// Detection runs once per process and the result is cached.
+func CurrentOSInfo() OSInfo {
+	osInfoOnce.Do(func() {
+		osInfo = detectOSInfo()
+		if osInfo.Name == "" {
+			osInfo.Name = runtime.GOOS
+		}
+		if osInfo.Version == "" {
+			osInfo.Version = "N/A (arch:" + runtime.GOARCH + ")"
+		}
+	})
+	return osInfo
+}
+
+// withOSRelease fills the distribution name, version and ID from the first readable os-release file in paths,
+// falling back to the kernel name and release for the values that aren't there.
+func withOSRelease(info OSInfo, paths []string) OSInfo {
+	for _, path := range paths {
+		values, err := ReadOSRelease(path)
+		if err != nil {
+			continue
+		}
+		info.Name = values["NAME"]
+		info.Version = values["VERSION_ID"]
+		info.ID = values["ID"]
+		break
+	}
+
+	if info.Name == "" {
+		info.Name = info.KernelName
+	}
+	if info.Version == "" {
+		info.Version = info.KernelRelease
+	}
+	return info
+}
+
+// ReadOSRelease parses an os-release file (see os-release(5)) into its key/value pairs.
+func ReadOSRelease(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseOSRelease(file)
+}
+
+// parseOSRelease reads the KEY=value lines of an os-release file, removing shell quoting from the values.
+func parseOSRelease(reader io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+			if parts[1][0] == '"' {
+				value = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\$`, `$`, "\\`", "`").Replace(value)
+			}
+		}
+		values[strings.TrimSpace(parts[0])] = value
+	}
+	return values, scanner.Err()
+}
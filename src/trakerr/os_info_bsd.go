@@ -0,0 +1,18 @@
+//go:build dragonfly || freebsd || netbsd || openbsd
+// +build dragonfly freebsd netbsd openbsd
+
+package trakerr
+
+import (
+	"syscall"
+)
+
+// detectOSInfo reads the OS name and release with sysctl(3).
+func detectOSInfo() OSInfo {
+	var info OSInfo
+	info.KernelName, _ = syscall.Sysctl("kern.ostype")
+	info.KernelRelease, _ = syscall.Sysctl("kern.osrelease")
+	info.Name = info.KernelName
+	info.Version = info.KernelRelease
+	return info
+}
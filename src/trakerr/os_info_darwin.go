@@ -0,0 +1,33 @@
+package trakerr
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// detectOSInfo reads the macOS and kernel versions with sysctl(3). system_profiler is only
+// run on releases older than 10.13.4, which don't have kern.osproductversion.
+func detectOSInfo() OSInfo {
+	info := OSInfo{Name: "macOS"}
+	info.KernelName, _ = syscall.Sysctl("kern.ostype")
+	info.KernelRelease, _ = syscall.Sysctl("kern.osrelease")
+
+	if version, err := syscall.Sysctl("kern.osproductversion"); err == nil && version != "" {
+		info.Version = version
+		return info
+	}
+
+	cmd := exec.Command("system_profiler", "SPSoftwareDataType")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err == nil {
+		systemVersion := getTextFromLine(out.String(), "System Version:", "(")
+		if fields := strings.Fields(systemVersion); len(fields) > 1 {
+			info.Name = strings.Join(fields[:len(fields)-1], " ")
+			info.Version = fields[len(fields)-1]
+		}
+	}
+	return info
+}
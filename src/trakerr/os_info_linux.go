@@ -0,0 +1,35 @@
+package trakerr
+
+import (
+	"syscall"
+)
+
+// osReleasePaths are the locations of the os-release file, in the order they are tried.
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// detectOSInfo reads the kernel name and release with uname(2) and the distribution from os-release.
+func detectOSInfo() OSInfo {
+	var info OSInfo
+
+	var uts syscall.Utsname
+	if err := syscall.Uname(&uts); err == nil {
+		sysname := make([]byte, 0, len(uts.Sysname))
+		for _, c := range uts.Sysname {
+			if c == 0 {
+				break
+			}
+			sysname = append(sysname, byte(c))
+		}
+		release := make([]byte, 0, len(uts.Release))
+		for _, c := range uts.Release {
+			if c == 0 {
+				break
+			}
+			release = append(release, byte(c))
+		}
+		info.KernelName = string(sysname)
+		info.KernelRelease = string(release)
+	}
+
+	return withOSRelease(info, osReleasePaths)
+}
@@ -0,0 +1,31 @@
+//go:build !linux && !darwin && !windows && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!windows,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package trakerr
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// detectOSInfo falls back to running uname(1) on platforms without a native API in the syscall package.
+func detectOSInfo() OSInfo {
+	var info OSInfo
+	info.KernelName = runUname("-s")
+	info.KernelRelease = runUname("-r")
+	info.Name = info.KernelName
+	info.Version = info.KernelRelease
+	return info
+}
+
+// runUname runs uname with a single flag and returns its trimmed output, or "" if it fails.
+func runUname(flag string) string {
+	cmd := exec.Command("uname", flag)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+	return strings.Trim(out.String(), " \r\n")
+}
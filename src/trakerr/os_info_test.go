@@ -0,0 +1,70 @@
+package trakerr
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	input := strings.Join([]string{
+		"# a comment",
+		"",
+		`NAME="Alpine Linux"`,
+		"ID=alpine",
+		"VERSION_ID='3.19.0'",
+		`PRETTY_NAME="Say \"hi\" for \$5 with \` + "`" + `echo\` + "`" + ` and \\"`,
+		"  HOME_URL = https://alpinelinux.org/  ",
+		"not a key value line",
+		"EMPTY=",
+	}, "\n")
+
+	values, err := parseOSRelease(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"NAME":        "Alpine Linux",
+		"ID":          "alpine",
+		"VERSION_ID":  "3.19.0",
+		"PRETTY_NAME": "Say \"hi\" for $5 with `echo` and \\",
+		"HOME_URL":    "https://alpinelinux.org/",
+		"EMPTY":       "",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("expected %q, got %q", want, values)
+	}
+}
+
+func TestParseOSReleaseKeepsSingleQuotedEscapes(t *testing.T) {
+	values, err := parseOSRelease(strings.NewReader(`NAME='a \"b\"'`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["NAME"] != `a \"b\"` {
+		t.Errorf("got %q", values["NAME"])
+	}
+}
+
+func TestWithOSRelease(t *testing.T) {
+	kernel := OSInfo{KernelName: "Linux", KernelRelease: "6.1.0"}
+	ubuntu := filepath.Join("testdata", "os-release", "ubuntu")
+	noName := filepath.Join("testdata", "os-release", "no-name")
+	missing := filepath.Join("testdata", "os-release", "missing")
+
+	for _, test := range []struct {
+		name  string
+		paths []string
+		want  OSInfo
+	}{
+		{"etc os-release", []string{ubuntu, noName}, OSInfo{Name: "Ubuntu", Version: "22.04", ID: "ubuntu", KernelName: "Linux", KernelRelease: "6.1.0"}},
+		{"usr lib fallback", []string{missing, ubuntu}, OSInfo{Name: "Ubuntu", Version: "22.04", ID: "ubuntu", KernelName: "Linux", KernelRelease: "6.1.0"}},
+		{"missing NAME", []string{noName}, OSInfo{Name: "Linux", Version: "7", ID: "custom", KernelName: "Linux", KernelRelease: "6.1.0"}},
+		{"no os-release", []string{missing}, OSInfo{Name: "Linux", Version: "6.1.0", KernelName: "Linux", KernelRelease: "6.1.0"}},
+	} {
+		if got := withOSRelease(kernel, test.paths); got != test.want {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.want, got)
+		}
+	}
+}
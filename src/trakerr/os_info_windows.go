@@ -0,0 +1,76 @@
+package trakerr
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// osVersionInfo mirrors the Win32 RTL_OSVERSIONINFOW structure.
+type osVersionInfo struct {
+	osVersionInfoSize uint32
+	majorVersion      uint32
+	minorVersion      uint32
+	buildNumber       uint32
+	platformID        uint32
+	csdVersion        [128]uint16
+}
+
+// detectOSInfo reads the version with RtlGetVersion, which unlike GetVersion isn't affected by
+// application manifests, and the product name from the registry. systeminfo is only run if both fail.
+func detectOSInfo() OSInfo {
+	info := OSInfo{KernelName: "Windows_NT"}
+
+	versionInfo := osVersionInfo{}
+	versionInfo.osVersionInfoSize = uint32(unsafe.Sizeof(versionInfo))
+	rtlGetVersion := syscall.NewLazyDLL("ntdll.dll").NewProc("RtlGetVersion")
+	if rtlGetVersion.Find() == nil {
+		if status, _, _ := rtlGetVersion.Call(uintptr(unsafe.Pointer(&versionInfo))); status == 0 {
+			info.Version = fmt.Sprintf("%d.%d.%d", versionInfo.majorVersion, versionInfo.minorVersion, versionInfo.buildNumber)
+			info.KernelRelease = info.Version
+		}
+	}
+
+	var key syscall.Handle
+	path, _ := syscall.UTF16PtrFromString(`SOFTWARE\Microsoft\Windows NT\CurrentVersion`)
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, path, 0, syscall.KEY_READ, &key); err == nil {
+		info.Name = registryString(key, "ProductName")
+		syscall.RegCloseKey(key)
+	}
+
+	if info.Name != "" || info.Version != "" {
+		return info
+	}
+
+	cmd := exec.Command("systeminfo")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err == nil {
+		var output = out.String()
+		info.Name = getTextFromLine(output, "OS Name:", "\n")
+		if fields := strings.Fields(getTextFromLine(output, "OS Version:", "\n")); len(fields) >= 1 {
+			info.Version = fields[0]
+		}
+	}
+	return info
+}
+
+// registryString returns the REG_SZ value name of the open key, or "" if it can't be read.
+func registryString(key syscall.Handle, name string) string {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return ""
+	}
+	var valueType, size uint32
+	if err := syscall.RegQueryValueEx(key, namePtr, nil, &valueType, nil, &size); err != nil || valueType != syscall.REG_SZ || size == 0 {
+		return ""
+	}
+	buffer := make([]uint16, size/2+1)
+	if err := syscall.RegQueryValueEx(key, namePtr, nil, &valueType, (*byte)(unsafe.Pointer(&buffer[0])), &size); err != nil {
+		return ""
+	}
+	return syscall.UTF16ToString(buffer)
+}
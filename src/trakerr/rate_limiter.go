@@ -0,0 +1,91 @@
+package trakerr
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by SendEvent when the event was dropped by the client's rate limiter,
+// or while Trakerr asked the client to back off with a 429 response.
+var ErrRateLimited = fmt.Errorf("%w: rate limited", ErrEventDropped)
+
+// tokenBucket allows rate events per second on average, with bursts of up to burst events. A 429 response
+// with a Retry-After header empties the bucket until the time the server asked for.
+type tokenBucket struct {
+	mu         sync.Mutex
+	rate       float64
+	burst      float64
+	tokens     float64
+	last       time.Time
+	retryAfter time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// allow takes a token from the bucket if one is available.
+func (bucket *tokenBucket) allow(now time.Time) bool {
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+	if now.Before(bucket.retryAfter) {
+		return false
+	}
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens += elapsed * bucket.rate
+		if bucket.tokens > bucket.burst {
+			bucket.tokens = bucket.burst
+		}
+	}
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// backOff stops the bucket from handing out tokens until until.
+func (bucket *tokenBucket) backOff(until time.Time) {
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+	if until.After(bucket.retryAfter) {
+		bucket.retryAfter = until
+		bucket.tokens = 0
+	}
+}
+
+// retryAfter returns when the server asked the client to send again in a 429 response, or the zero time.
+func retryAfter(response *APIResponse, now time.Time) time.Time {
+	if response == nil || response.Response == nil || response.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}
+	}
+	value := response.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date
+	}
+	return time.Time{}
+}
+
+// SetRateLimit limits the events sent to Trakerr to eventsPerSecond on average, with bursts of up to burst
+// events. Events over the limit are dropped and SendEvent returns ErrRateLimited. While the limit is set, a 429
+// response with a Retry-After header also drops events until the time the server asked for.
+// An eventsPerSecond of 0 removes the limit.
+func (trakerrClient *TrakerrClient) SetRateLimit(eventsPerSecond float64, burst int) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	if eventsPerSecond <= 0 {
+		trakerrClient.limiter = nil
+		return
+	}
+	trakerrClient.limiter = newTokenBucket(eventsPerSecond, burst, time.Now())
+}
@@ -0,0 +1,67 @@
+package trakerr
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Unix(0, 0)
+	bucket := newTokenBucket(2, 3, start)
+
+	for i := 0; i < 3; i++ {
+		if !bucket.allow(start) {
+			t.Fatalf("expected the burst to allow event %d", i)
+		}
+	}
+	if bucket.allow(start) {
+		t.Fatal("expected the bucket to be empty after the burst")
+	}
+	if !bucket.allow(start.Add(500 * time.Millisecond)) {
+		t.Fatal("expected a token after half a second at 2 events per second")
+	}
+	if bucket.allow(start.Add(600 * time.Millisecond)) {
+		t.Fatal("expected no token 100ms later")
+	}
+
+	bucket.backOff(start.Add(time.Minute))
+	if bucket.allow(start.Add(30 * time.Second)) {
+		t.Error("expected no events before Retry-After")
+	}
+	if !bucket.allow(start.Add(time.Minute + time.Second)) {
+		t.Error("expected events again after Retry-After")
+	}
+}
+
+func TestRateLimitHonoursRetryAfter(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t)
+	client.eventsAPI = *NewEventsApiWithBasePath(server.URL)
+	client.SetRateLimit(100, 10)
+
+	response, err := client.SendEvent(client.NewEmptyEvent())
+	if err != nil || response.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the 429 to be returned, got %v, %v", response, err)
+	}
+	if _, err := client.SendEvent(client.NewEmptyEvent()); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited while backing off, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+	client.SetRateLimit(0, 0)
+	if _, err := client.SendEvent(client.NewEmptyEvent()); err != nil || requests != 2 {
+		t.Errorf("expected removing the limit to send again, got %v after %d requests", err, requests)
+	}
+}
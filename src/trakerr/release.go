@@ -0,0 +1,94 @@
+package trakerr
+
+import (
+	"errors"
+	"time"
+)
+
+// ReleaseEventType is the EventType of release marker events.
+const ReleaseEventType = "Release"
+
+// Tag keys of release marker events.
+const (
+	ReleaseVersionTagKey   = "release.version"
+	ReleaseRevisionTagKey  = "release.revision"
+	ReleaseChangelogTagKey = "release.changelog_url"
+	ReleaseDeployerTagKey  = "release.deployer"
+)
+
+// Release describes a deploy of a version of the application, sent to Trakerr as a marker so changes in the
+// events can be correlated with it.
+type Release struct {
+
+	// the ContextAppVersion that was deployed
+	Version string
+
+	// the deployment stage it was deployed to, defaults to the stage of the client
+	DeploymentStage string
+
+	// VCS revision the version was built from
+	Revision string
+
+	// link to the changes in the release
+	ChangelogURL string
+
+	// who or what deployed it, eg. a user name or a CI job
+	Deployer string
+
+	// when it was deployed, defaults to now
+	Time time.Time
+}
+
+// NewRelease returns the Release of the running binary: the app version and deployment stage of the client and
+// the VCS revision from the build information.
+func (trakerrClient *TrakerrClient) NewRelease() Release {
+	trakerrClient.mu.RLock()
+	defer trakerrClient.mu.RUnlock()
+	return Release{
+		Version:         trakerrClient.contextAppVersion,
+		DeploymentStage: trakerrClient.contextDeploymentStage,
+		Revision:        CurrentBuildInfo().Revision,
+	}
+}
+
+// SendRelease sends a release marker event. It isn't sampled or deduplicated.
+func (trakerrClient *TrakerrClient) SendRelease(release Release) (*APIResponse, error) {
+	if release.Version == "" {
+		return nil, errors.New("trakerr: a release needs a version")
+	}
+	return trakerrClient.post(trakerrClient.releaseEvent(release))
+}
+
+// releaseEvent returns the marker event of release.
+func (trakerrClient *TrakerrClient) releaseEvent(release Release) *AppEvent {
+	message := "Release " + release.Version
+	if release.DeploymentStage != "" {
+		message += " deployed to " + release.DeploymentStage
+	}
+	if release.Deployer != "" {
+		message += " by " + release.Deployer
+	}
+
+	appEvent := trakerrClient.NewAppEvent("info", "", ReleaseEventType, message)
+	appEvent.ContextAppVersion = release.Version
+	if release.DeploymentStage != "" {
+		appEvent.DeploymentStage = release.DeploymentStage
+	}
+	if !release.Time.IsZero() {
+		appEvent.EventTime = release.Time.UnixNano() / int64(time.Millisecond)
+	}
+
+	tags := map[string]string{ReleaseVersionTagKey: release.Version}
+	if release.Revision != "" {
+		tags[ReleaseRevisionTagKey] = release.Revision
+	}
+	if release.ChangelogURL != "" {
+		tags[ReleaseChangelogTagKey] = release.ChangelogURL
+	}
+	if release.Deployer != "" {
+		tags[ReleaseDeployerTagKey] = release.Deployer
+	}
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, tags)
+	trakerrClient.fillContextTags(appEvent)
+	return appEvent
+}
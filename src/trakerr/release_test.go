@@ -0,0 +1,63 @@
+package trakerr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSendRelease(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetSampleRate(0) // release markers are not sampled
+
+	release := client.NewRelease()
+	if release.Version != "1.0" || release.DeploymentStage != "test" {
+		t.Errorf("NewRelease() = %+v", release)
+	}
+	release.Version = "1.4.2"
+	release.DeploymentStage = "production"
+	release.Revision = "0123abcd"
+	release.ChangelogURL = "https://example.com/releases/1.4.2"
+	release.Deployer = "alice"
+	release.Time = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := client.SendRelease(release); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SendRelease(release); err != nil {
+		t.Fatalf("a repeated release marker was dropped: %v", err)
+	}
+
+	events := recorder.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	marker := events[0]
+	if marker.EventType != ReleaseEventType || marker.LogLevel != "info" || marker.EventMessage != "Release 1.4.2 deployed to production by alice" {
+		t.Errorf("unexpected marker %q %q %q", marker.EventType, marker.LogLevel, marker.EventMessage)
+	}
+	if marker.ContextAppVersion != "1.4.2" || marker.DeploymentStage != "production" || marker.EventTime != release.Time.UnixNano()/int64(time.Millisecond) {
+		t.Errorf("unexpected context %q %q %d", marker.ContextAppVersion, marker.DeploymentStage, marker.EventTime)
+	}
+	for _, tag := range []string{"release.version:1.4.2", "release.revision:0123abcd", "release.changelog_url:https://example.com/releases/1.4.2", "release.deployer:alice"} {
+		if !hasTag(marker, tag) {
+			t.Errorf("tag %s missing from %v", tag, marker.ContextTags)
+		}
+	}
+
+	if _, err := client.SendRelease(Release{}); err == nil {
+		t.Error("a release without a version was sent")
+	}
+}
+
+func TestSetBaseURL(t *testing.T) {
+	_, recorder := newTestClient(t)
+	client := NewTrakerrClient("test-api-key", "1.0", "test")
+	other, _ := newTestClient(t)
+	client.SetBaseURL(other.eventsAPI.Configuration.BasePath + "/")
+
+	if _, err := client.SendEvent(client.NewAppEvent("info", "", "Type", "message")); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Events()) != 0 {
+		t.Error("the event was sent to the wrong server")
+	}
+}
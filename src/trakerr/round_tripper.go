@@ -0,0 +1,171 @@
+package trakerr
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CorrelationIDHeader is the request header the correlation ID of the Scope is sent to downstream services in.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// HTTPHostTagKey is the tag key of the host called by a request reported by the RoundTripper.
+const HTTPHostTagKey = "http.host"
+
+// pathIDPattern matches the UUIDs and hexadecimal IDs among the path segments replaced by PathTemplate.
+var pathIDPattern = regexp.MustCompile(`(?i)^(?:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{8,})$`)
+
+// PathTemplate replaces the path segments that look like IDs with "{id}", so "/users/42/orders/9f8e7d6c" becomes
+// "/users/{id}/orders/{id}". Numbers, UUIDs and hexadecimal strings of 8 or more characters with a digit are IDs.
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.ParseUint(segment, 10, 64); err == nil || (pathIDPattern.MatchString(segment) && strings.ContainsAny(segment, "0123456789")) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// RoundTripperOptions configures RoundTripperWithOptions. Zero values use the defaults.
+type RoundTripperOptions struct {
+
+	// classes of the response status codes reported, eg. 4 for 4xx; defaults to 5xx only
+	ReportStatusClasses []int
+
+	// returns the path template of the request, defaults to PathTemplate of the URL path
+	PathTemplate func(r *http.Request) string
+
+	// header the correlation ID of the Scope carried by the request context is sent in, defaults to CorrelationIDHeader
+	CorrelationHeader string
+
+	// scrubs the query values of the reported URL, defaults to NewScrubber(); the values of its DenyKeys are redacted
+	Scrubber *Scrubber
+
+	// log level of the events, defaults to "error"
+	LogLevel string
+
+	// Classification of the events, defaults to the client default
+	Classification string
+}
+
+// RoundTripper returns an http.RoundTripper that sends requests with next, or with the http.DefaultTransport of
+// the time of the call when next is nil, so the wrapper can itself be installed as http.DefaultTransport. Transport errors and 5xx responses are sent to Trakerr with the method, host, path template and duration,
+// every request is recorded as an HTTP breadcrumb, and the correlation ID of the Scope carried by the request
+// context is sent in the CorrelationIDHeader. Within a span, each request runs in a child span whose trace
+// context is sent in the traceparent and tracestate headers.
+//
+//	httpClient := &http.Client{Transport: client.RoundTripper(nil)}
+//	response, err := httpClient.Do(request.WithContext(ctx))
+func (trakerrClient *TrakerrClient) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return trakerrClient.RoundTripperWithOptions(next, nil)
+}
+
+// RoundTripperWithOptions is RoundTripper with options, which may be nil.
+func (trakerrClient *TrakerrClient) RoundTripperWithOptions(next http.RoundTripper, options *RoundTripperOptions) http.RoundTripper {
+	roundTripper := &roundTripper{client: trakerrClient, next: next}
+	// resolved now rather than per request, which would recurse once the wrapper is the default transport
+	if roundTripper.next == nil {
+		roundTripper.next = http.DefaultTransport
+	}
+	if options != nil {
+		roundTripper.options = *options
+	}
+	if roundTripper.options.ReportStatusClasses == nil {
+		roundTripper.options.ReportStatusClasses = []int{5}
+	}
+	if roundTripper.options.PathTemplate == nil {
+		roundTripper.options.PathTemplate = func(r *http.Request) string { return PathTemplate(r.URL.Path) }
+	}
+	if roundTripper.options.CorrelationHeader == "" {
+		roundTripper.options.CorrelationHeader = CorrelationIDHeader
+	}
+	if roundTripper.options.Scrubber == nil {
+		roundTripper.options.Scrubber = NewScrubber()
+	}
+	if roundTripper.options.LogLevel == "" {
+		roundTripper.options.LogLevel = "error"
+	}
+	return roundTripper
+}
+
+type roundTripper struct {
+	client  *TrakerrClient
+	next    http.RoundTripper
+	options RoundTripperOptions
+}
+
+// RoundTrip implements http.RoundTripper.
+func (roundTripper *roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	scope := ScopeFromContext(ctx)
+	propagateCorrelation := scope != nil && scope.CorrelationID() != "" && r.Header.Get(roundTripper.options.CorrelationHeader) == ""
+	var span *Span
+	if SpanFromContext(ctx) != nil {
+		ctx, span = roundTripper.client.StartSpan(ctx, r.Method+" "+r.URL.Host+roundTripper.options.PathTemplate(r))
+	}
+	if propagateCorrelation || span != nil {
+		// a RoundTripper must not modify the request it is given
+		r = r.Clone(r.Context())
+		if propagateCorrelation {
+			r.Header.Set(roundTripper.options.CorrelationHeader, scope.CorrelationID())
+		}
+		InjectTraceContext(ctx, r.Header)
+	}
+
+	start := time.Now()
+	response, err := roundTripper.next.RoundTrip(r)
+	duration := time.Since(start)
+
+	status := 0
+	if err == nil {
+		status = response.StatusCode
+	}
+	if span != nil {
+		if err != nil || status >= 500 {
+			span.SetStatus(SpanStatusError)
+		}
+		defer span.Finish()
+	}
+	scrubbedURL := scrubURL(r.URL, roundTripper.options.Scrubber)
+	roundTripper.client.AddHTTPBreadcrumb(ctx, r.Method, scrubbedURL, status, duration)
+
+	var appEvent *AppEvent
+	hint := Hint{}
+	if err != nil {
+		appEvent = roundTripper.client.createAppEventFromErrorWithSkipContext(ctx, err, roundTripper.options.LogLevel, roundTripper.options.Classification, 1)
+		appEvent.EventMessage = fmt.Sprintf("%s %s%s: %v", r.Method, r.URL.Host, roundTripper.options.PathTemplate(r), err)
+		hint.Error = err
+	} else if roundTripper.reportStatus(status) {
+		message := fmt.Sprintf("%s %s%s returned %d %s", r.Method, r.URL.Host, roundTripper.options.PathTemplate(r), status, http.StatusText(status))
+		appEvent = roundTripper.client.NewAppEvent(roundTripper.options.LogLevel, roundTripper.options.Classification, "HTTP "+strconv.Itoa(status), message)
+	} else {
+		return response, err
+	}
+
+	appEvent.ContextURL = scrubbedURL
+	appEvent.ContextOperationTimeMillis = int64(duration / time.Millisecond)
+	tags := map[string]string{
+		HTTPMethodTagKey: r.Method,
+		HTTPHostTagKey:   r.URL.Host,
+		HTTPRouteTagKey:  roundTripper.options.PathTemplate(r),
+	}
+	if status != 0 {
+		tags[HTTPStatusCodeTagKey] = strconv.Itoa(status)
+	}
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, tags)
+	roundTripper.client.sendContext(ctx, appEvent, hint)
+	return response, err
+}
+
+func (roundTripper *roundTripper) reportStatus(status int) bool {
+	for _, class := range roundTripper.options.ReportStatusClasses {
+		if status/100 == class {
+			return true
+		}
+	}
+	return false
+}
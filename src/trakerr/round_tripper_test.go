@@ -0,0 +1,124 @@
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc adapts a function to an http.RoundTripper.
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestPathTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/users/42/orders/9f8e7d6c":                        "/users/{id}/orders/{id}",
+		"/items/123e4567-e89b-12d3-a456-426614174000/tags": "/items/{id}/tags",
+		"/v2/accounts/deadbeef":                            "/v2/accounts/deadbeef",
+		"/":                                                "/",
+	}
+	for path, want := range tests {
+		if got := PathTemplate(path); got != want {
+			t.Errorf("PathTemplate(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRoundTripperReportsServerErrors(t *testing.T) {
+	client, recorder := newTestClient(t)
+	var received string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(CorrelationIDHeader)
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer downstream.Close()
+
+	ctx, scope := WithScope(context.Background())
+	scope.SetCorrelationID("corr-1")
+	httpClient := &http.Client{Transport: client.RoundTripper(nil)}
+	for _, path := range []string{"/missing/1", "/users/42?token=secret"} {
+		request, _ := http.NewRequestWithContext(ctx, "GET", downstream.URL+path, nil)
+		response, err := httpClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if request.Header.Get(CorrelationIDHeader) != "" {
+			t.Error("the caller's request was modified")
+		}
+	}
+
+	if received != "corr-1" {
+		t.Errorf("correlation ID not propagated, got %q", received)
+	}
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected only the 5xx response to be reported, got %d events", len(events))
+	}
+	event := events[0]
+	host := strings.TrimPrefix(downstream.URL, "http://")
+	if event.EventType != "HTTP 502" || event.EventMessage != "GET "+host+"/users/{id} returned 502 Bad Gateway" {
+		t.Errorf("unexpected event %q %q", event.EventType, event.EventMessage)
+	}
+	if event.ContextURL != downstream.URL+"/users/42?token=%5BREDACTED%5D" || event.ContextCrossAppCorrelationId != "corr-1" {
+		t.Errorf("unexpected context %q %q", event.ContextURL, event.ContextCrossAppCorrelationId)
+	}
+	for _, tag := range []string{"http.method:GET", "http.host:" + host, "http.route:/users/{id}", "http.status_code:502"} {
+		if !hasTag(event, tag) {
+			t.Errorf("tag %s missing from %v", tag, event.ContextTags)
+		}
+	}
+	if crumbs := scope.Breadcrumbs(); len(crumbs) != 2 || crumbs[0].Data["status_code"] != "404" {
+		t.Errorf("unexpected breadcrumbs %+v", crumbs)
+	}
+}
+
+func TestRoundTripperReportsTransportErrors(t *testing.T) {
+	client, recorder := newTestClient(t)
+	refused := errors.New("connection refused")
+	transport := client.RoundTripperWithOptions(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, refused
+	}), &RoundTripperOptions{ReportStatusClasses: []int{4, 5}, Classification: "downstream"})
+
+	request, _ := http.NewRequest("POST", "http://api.example.com/orders/7", nil)
+	if _, err := transport.RoundTrip(request); err != refused {
+		t.Errorf("expected the transport error, got %v", err)
+	}
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventMessage != "POST api.example.com/orders/{id}: connection refused" || event.Classification != "downstream" {
+		t.Errorf("unexpected event %q %q", event.EventMessage, event.Classification)
+	}
+	if hasTag(event, "http.status_code:0") || !hasTag(event, "http.host:api.example.com") {
+		t.Errorf("unexpected tags %v", event.ContextTags)
+	}
+}
+
+func TestRoundTripperInstalledAsDefaultTransport(t *testing.T) {
+	client, _ := newTestClient(t)
+	defaultTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = defaultTransport }()
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+	})
+	http.DefaultTransport = client.RoundTripper(nil)
+
+	request, _ := http.NewRequest("GET", "http://api.example.com/health", nil)
+	response, err := http.DefaultTransport.RoundTrip(request)
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Errorf("expected the transport the wrapper was created with to send the request, got %v, %v", response, err)
+	}
+}
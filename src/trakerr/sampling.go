@@ -0,0 +1,164 @@
+package trakerr
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// SampleRateTagKey is the tag that records the rate an event was sampled at, so counts can be extrapolated
+// on the server. It is only added to events sampled at a rate below 1.
+const SampleRateTagKey = "sample_rate"
+
+// ErrEventDropped is wrapped by the errors returned when the client deliberately doesn't send an event.
+// The Recover and Notify methods ignore these errors.
+var ErrEventDropped = errors.New("trakerr: event dropped")
+
+// ErrSampled is returned by SendEvent when the event was dropped by sampling.
+var ErrSampled = fmt.Errorf("%w: sampled out", ErrEventDropped)
+
+// Sampler returns the rate, between 0 and 1, at which an event is kept. A negative rate falls back to
+// the rates configured on the client.
+type Sampler func(appEvent *AppEvent) float64
+
+// samplingRules holds the sample rates of a TrakerrClient. The most specific rate applies: the Sampler,
+// then the event type, the classification, the log level and finally the global rate.
+type samplingRules struct {
+	rate                float64
+	levelRates          map[string]float64
+	eventTypeRates      map[string]float64
+	classificationRates map[string]float64
+	sampler             Sampler
+}
+
+func newSamplingRules() samplingRules {
+	return samplingRules{rate: 1}
+}
+
+// rateFor returns the sample rate that applies to appEvent.
+func (rules samplingRules) rateFor(appEvent *AppEvent) float64 {
+	if rules.sampler != nil {
+		if rate := rules.sampler(appEvent); rate >= 0 {
+			return rate
+		}
+	}
+	if rate, ok := rules.eventTypeRates[appEvent.EventType]; ok {
+		return rate
+	}
+	if rate, ok := rules.classificationRates[strings.ToLower(appEvent.Classification)]; ok {
+		return rate
+	}
+	if rate, ok := rules.levelRates[sampleLevel(appEvent.LogLevel)]; ok {
+		return rate
+	}
+	return rules.rate
+}
+
+// sampleLevel returns the key of a log level in the level rates, spelling "warn" out as "warning" so both
+// spellings share a rate.
+func sampleLevel(loglevel string) string {
+	loglevel = normalizeLogLevel(loglevel)
+	if loglevel == "warn" {
+		return "warning"
+	}
+	return loglevel
+}
+
+// sampleValue returns a number in [0, 1) that decides whether an event is kept. Events with a correlation ID
+// hash it, so every event of one request is either kept or dropped on every service using the same rate.
+func sampleValue(appEvent *AppEvent) float64 {
+	if appEvent.ContextCrossAppCorrelationId == "" {
+		return rand.Float64()
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(appEvent.ContextCrossAppCorrelationId))
+	//The high bits of FNV-1a barely change between similar IDs (request-1, request-2), so mix them in
+	//with the 64-bit finalizer of MurmurHash3 before taking the top 53 bits.
+	sum := hash.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	sum *= 0xc4ceb9fe1a85ec53
+	sum ^= sum >> 33
+	return float64(sum>>11) / (1 << 53)
+}
+
+func clampRate(rate float64) float64 {
+	if rate < 0 {
+		return 0
+	}
+	if rate > 1 {
+		return 1
+	}
+	return rate
+}
+
+// withRate returns a copy of rates with key set to rate. The maps are never changed in place, so sample can
+// read them after releasing the client lock.
+func withRate(rates map[string]float64, key string, rate float64) map[string]float64 {
+	result := make(map[string]float64, len(rates)+1)
+	for k, v := range rates {
+		result[k] = v
+	}
+	result[key] = clampRate(rate)
+	return result
+}
+
+// SetSampleRate sets the rate, between 0 and 1, at which events without a more specific rate are sent.
+// The default is 1, which sends every event.
+func (trakerrClient *TrakerrClient) SetSampleRate(rate float64) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.sampling.rate = clampRate(rate)
+}
+
+// SetLevelSampleRate sets the sample rate of the events with the given log level, for example 0.1 for "warning".
+func (trakerrClient *TrakerrClient) SetLevelSampleRate(loglevel string, rate float64) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.sampling.levelRates = withRate(trakerrClient.sampling.levelRates, sampleLevel(loglevel), rate)
+}
+
+// SetEventTypeSampleRate sets the sample rate of the events with the given EventType, overriding the level
+// and classification rates.
+func (trakerrClient *TrakerrClient) SetEventTypeSampleRate(eventType string, rate float64) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.sampling.eventTypeRates = withRate(trakerrClient.sampling.eventTypeRates, eventType, rate)
+}
+
+// SetClassificationSampleRate sets the sample rate of the events with the given classification, overriding
+// the level rate.
+func (trakerrClient *TrakerrClient) SetClassificationSampleRate(classification string, rate float64) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.sampling.classificationRates = withRate(trakerrClient.sampling.classificationRates, strings.ToLower(classification), rate)
+}
+
+// SetSampler sets a function that decides the sample rate of each event ahead of the configured rates.
+// Pass nil to remove it.
+func (trakerrClient *TrakerrClient) SetSampler(sampler Sampler) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.sampling.sampler = sampler
+}
+
+// sample decides whether appEvent is sent and records the sample rate on the events that are.
+func (trakerrClient *TrakerrClient) sample(appEvent *AppEvent) bool {
+	trakerrClient.mu.RLock()
+	rules := trakerrClient.sampling
+	trakerrClient.mu.RUnlock()
+
+	rate := clampRate(rules.rateFor(appEvent))
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 || sampleValue(appEvent) >= rate {
+		return false
+	}
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, map[string]string{SampleRateTagKey: strconv.FormatFloat(rate, 'g', -1, 64)})
+	return true
+}
@@ -0,0 +1,116 @@
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSamplingRatePrecedence(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.SetSampleRate(0.5)
+	client.SetLevelSampleRate("Warning", 0.25)
+	client.SetClassificationSampleRate("Perf", 0.1)
+	client.SetEventTypeSampleRate("*errors.errorString", 0.75)
+
+	rules := client.sampling
+	for _, test := range []struct {
+		event AppEvent
+		rate  float64
+	}{
+		{AppEvent{LogLevel: "info"}, 0.5},
+		{AppEvent{LogLevel: "warning"}, 0.25},
+		{AppEvent{LogLevel: "warning", Classification: "perf"}, 0.1},
+		{AppEvent{LogLevel: "warning", Classification: "perf", EventType: "*errors.errorString"}, 0.75},
+	} {
+		if rate := rules.rateFor(&test.event); rate != test.rate {
+			t.Errorf("expected rate %v for %+v, got %v", test.rate, test.event, rate)
+		}
+	}
+
+	client.SetSampler(func(appEvent *AppEvent) float64 {
+		if appEvent.EventUser == "vip" {
+			return 1
+		}
+		return -1
+	})
+	rules = client.sampling
+	if rate := rules.rateFor(&AppEvent{LogLevel: "warning", EventUser: "vip"}); rate != 1 {
+		t.Errorf("expected the sampler to win, got %v", rate)
+	}
+	if rate := rules.rateFor(&AppEvent{LogLevel: "warning"}); rate != 0.25 {
+		t.Errorf("expected a negative sampler rate to fall back, got %v", rate)
+	}
+}
+
+func TestLevelSampleRateMatchesWarnSpellings(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetLevelSampleRate("warning", 0)
+	if _, err := client.SendEvent(client.NewAppEvent("warn", "", "Type", "message")); !errors.Is(err, ErrSampled) {
+		t.Errorf("a warn event was not sampled by the warning rate: %v", err)
+	}
+	client.SetLevelSampleRate("warning", 1)
+	client.SetLevelSampleRate("WARN", 0)
+	if _, err := client.SendEvent(client.NewAppEvent("warning", "", "Type", "message")); !errors.Is(err, ErrSampled) {
+		t.Errorf("a warning event was not sampled by the warn rate: %v", err)
+	}
+	if len(recorder.Events()) != 0 {
+		t.Errorf("sampled events were sent: %d", len(recorder.Events()))
+	}
+}
+
+func TestSamplingIsDeterministicByCorrelationID(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetSampleRate(0.5)
+
+	kept := 0
+	for i := 0; i < 200; i++ {
+		event := client.NewAppEvent("info", "", "Type", "message")
+		event.ContextCrossAppCorrelationId = fmt.Sprintf("request-%d", i)
+		first, firstErr := client.SendEvent(event)
+		_, secondErr := client.SendEvent(event)
+		if (firstErr == nil) != (secondErr == nil) {
+			t.Fatalf("correlation ID %q was sampled inconsistently", event.ContextCrossAppCorrelationId)
+		}
+		if firstErr == nil {
+			kept++
+		} else if !errors.Is(firstErr, ErrSampled) || first != nil {
+			t.Fatalf("unexpected result %v, %v", first, firstErr)
+		}
+	}
+	if kept < 60 || kept > 140 {
+		t.Errorf("expected about half of the events to be kept, got %d of 200", kept)
+	}
+
+	for _, event := range recorder.Events() {
+		if !reflect.DeepEqual(event.ContextTags, []string{"sample_rate:0.5"}) {
+			t.Fatalf("expected the sample rate tag, got %v", event.ContextTags)
+		}
+	}
+}
+
+func TestRecoverIgnoresSampledEvents(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetLevelSampleRate("error", 0)
+
+	func() {
+		defer client.Recover("error", "")
+		panic("dropped")
+	}()
+	func() {
+		defer client.RecoverContext(context.Background(), "fatal", "")
+		panic("kept")
+	}()
+
+	events := recorder.Events()
+	if len(events) != 1 || events[0].EventMessage != "kept" || events[0].LogLevel != "fatal" {
+		t.Errorf("expected only the fatal event, got %+v", events)
+	}
+	for _, tag := range events[0].ContextTags {
+		if tag == "sample_rate:1" {
+			t.Error("events sent at rate 1 shouldn't be tagged")
+		}
+	}
+}
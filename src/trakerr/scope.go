@@ -0,0 +1,216 @@
+package trakerr
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Scope holds per-request data (user, session, correlation ID, tags, custom properties and breadcrumbs)
+// that is merged into every event sent with a context carrying the scope.
+// The zero value is an empty scope ready to use. A Scope is safe for concurrent use, but goroutines that need to change it independently should fork
+// their own copy with WithScope instead of sharing one.
+type Scope struct {
+	mu               sync.RWMutex
+	user             string
+	session          string
+	correlationID    string
+	tags             map[string]string
+	customProperties CustomData
+	breadcrumbs      *BreadcrumbBuffer
+	processors       []EventProcessor
+}
+
+// NewScope returns an empty Scope with a breadcrumb trail of DefaultMaxBreadcrumbs.
+func NewScope() *Scope {
+	return &Scope{
+		tags:        make(map[string]string),
+		breadcrumbs: NewBreadcrumbBuffer(DefaultMaxBreadcrumbs),
+	}
+}
+
+type scopeContextKey struct{}
+
+// WithScope forks the scope carried by ctx (or starts an empty one) and returns a context carrying the copy.
+// Changes made to the returned scope are not visible to the parent context or to other forks.
+func WithScope(ctx context.Context) (context.Context, *Scope) {
+	var scope *Scope
+	if parent := ScopeFromContext(ctx); parent != nil {
+		scope = parent.Clone()
+	} else {
+		scope = NewScope()
+	}
+	return context.WithValue(ctx, scopeContextKey{}, scope), scope
+}
+
+// ScopeFromContext returns the scope carried by ctx, or nil if there is none.
+func ScopeFromContext(ctx context.Context) *Scope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(scopeContextKey{}).(*Scope)
+	return scope
+}
+
+// Clone returns a deep copy of the scope, including its breadcrumb trail.
+func (scope *Scope) Clone() *Scope {
+	breadcrumbs := scope.trail()
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	clone := &Scope{
+		user:             scope.user,
+		session:          scope.session,
+		correlationID:    scope.correlationID,
+		tags:             make(map[string]string, len(scope.tags)),
+		customProperties: scope.customProperties,
+		breadcrumbs:      NewBreadcrumbBuffer(breadcrumbs.Cap()),
+		processors:       scope.processors[:len(scope.processors):len(scope.processors)],
+	}
+	for key, value := range scope.tags {
+		clone.tags[key] = value
+	}
+	for _, crumb := range breadcrumbs.Snapshot() {
+		clone.breadcrumbs.Add(crumb)
+	}
+	return clone
+}
+
+// SetUser sets the EventUser for events sent with this scope.
+func (scope *Scope) SetUser(user string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.user = user
+}
+
+// SetSession sets the EventSession for events sent with this scope.
+func (scope *Scope) SetSession(session string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.session = session
+}
+
+// SetCorrelationID sets the ContextCrossAppCorrelationId for events sent with this scope.
+func (scope *Scope) SetCorrelationID(correlationID string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.correlationID = correlationID
+}
+
+// CorrelationID returns the correlation ID set on the scope.
+func (scope *Scope) CorrelationID() string {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+	return scope.correlationID
+}
+
+// SetTag sets a tag that is added to the ContextTags of events sent with this scope as "key:value".
+func (scope *Scope) SetTag(key string, value string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if scope.tags == nil {
+		scope.tags = make(map[string]string)
+	}
+	scope.tags[key] = value
+}
+
+// RemoveTag removes a tag set with SetTag.
+func (scope *Scope) RemoveTag(key string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	delete(scope.tags, key)
+}
+
+// SetCustomProperties sets the custom properties merged into events sent with this scope.
+// Slots already filled on an event are left alone.
+func (scope *Scope) SetCustomProperties(customProperties CustomData) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.customProperties = customProperties
+}
+
+// AddBreadcrumb records a breadcrumb on the scope's trail.
+func (scope *Scope) AddBreadcrumb(crumb Breadcrumb) {
+	scope.trail().Add(crumb)
+}
+
+// Breadcrumbs returns a copy of the scope's breadcrumb trail, oldest first.
+func (scope *Scope) Breadcrumbs() []Breadcrumb {
+	return scope.trail().Snapshot()
+}
+
+// trail returns the breadcrumb trail of the scope, starting one of DefaultMaxBreadcrumbs for a zero-value Scope.
+func (scope *Scope) trail() *BreadcrumbBuffer {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if scope.breadcrumbs == nil {
+		scope.breadcrumbs = NewBreadcrumbBuffer(DefaultMaxBreadcrumbs)
+	}
+	return scope.breadcrumbs
+}
+
+// ApplyToEvent merges the scope into appEvent. Values already set on the event take precedence.
+func (scope *Scope) ApplyToEvent(appEvent *AppEvent) {
+	breadcrumbs := scope.trail()
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	if appEvent.EventUser == "" {
+		appEvent.EventUser = scope.user
+	}
+	if appEvent.EventSession == "" {
+		appEvent.EventSession = scope.session
+	}
+	if appEvent.ContextCrossAppCorrelationId == "" {
+		appEvent.ContextCrossAppCorrelationId = scope.correlationID
+	}
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, scope.tags)
+	mergeCustomData(&appEvent.CustomProperties, scope.customProperties)
+	if appEvent.Breadcrumbs == nil {
+		appEvent.Breadcrumbs = breadcrumbs.Snapshot()
+	}
+}
+
+// formatTag renders a key/value pair the way it is sent in ContextTags.
+func formatTag(key string, value string) string {
+	return key + ":" + value
+}
+
+// mergeTags appends the tags whose key isn't already present in existing, sorted by key.
+func mergeTags(existing []string, tags map[string]string) []string {
+	if len(tags) == 0 {
+		return existing
+	}
+	present := make(map[string]bool, len(existing))
+	for _, tag := range existing {
+		present[strings.SplitN(tag, ":", 2)[0]] = true
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		if !present[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		existing = append(existing, formatTag(key, tags[key]))
+	}
+	return existing
+}
+
+// mergeCustomData copies every non-empty slot of src into the matching empty slot of dst.
+func mergeCustomData(dst *CustomData, src CustomData) {
+	mergeSlots(reflect.ValueOf(&dst.StringData).Elem(), reflect.ValueOf(src.StringData))
+	mergeSlots(reflect.ValueOf(&dst.DoubleData).Elem(), reflect.ValueOf(src.DoubleData))
+}
+
+func mergeSlots(dst reflect.Value, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		zero := reflect.Zero(dst.Field(i).Type()).Interface()
+		if dst.Field(i).Interface() == zero && src.Field(i).Interface() != zero {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package trakerr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestWithScopeForkDoesNotLeakToParent(t *testing.T) {
+	parentCtx, parent := WithScope(context.Background())
+	parent.SetUser("parent")
+	parent.SetTag("shared", "parent")
+	parent.AddBreadcrumb(Breadcrumb{Message: "parent"})
+
+	childCtx, child := WithScope(parentCtx)
+	if ScopeFromContext(childCtx) != child || ScopeFromContext(parentCtx) != parent {
+		t.Fatal("contexts don't carry their own scopes")
+	}
+	child.SetUser("child")
+	child.SetTag("shared", "child")
+	child.SetTag("child-only", "x")
+	child.AddBreadcrumb(Breadcrumb{Message: "child"})
+
+	parentEvent := &AppEvent{}
+	parent.ApplyToEvent(parentEvent)
+	if parentEvent.EventUser != "parent" {
+		t.Errorf("parent user changed to %q", parentEvent.EventUser)
+	}
+	if !reflect.DeepEqual(parentEvent.ContextTags, []string{"shared:parent"}) {
+		t.Errorf("parent tags changed to %v", parentEvent.ContextTags)
+	}
+	if len(parentEvent.Breadcrumbs) != 1 || parentEvent.Breadcrumbs[0].Message != "parent" {
+		t.Errorf("parent breadcrumbs changed to %+v", parentEvent.Breadcrumbs)
+	}
+
+	childEvent := &AppEvent{}
+	child.ApplyToEvent(childEvent)
+	if childEvent.EventUser != "child" {
+		t.Errorf("expected child user, got %q", childEvent.EventUser)
+	}
+	if !reflect.DeepEqual(childEvent.ContextTags, []string{"child-only:x", "shared:child"}) {
+		t.Errorf("unexpected child tags %v", childEvent.ContextTags)
+	}
+	if len(childEvent.Breadcrumbs) != 2 {
+		t.Errorf("expected the inherited and the child breadcrumb, got %+v", childEvent.Breadcrumbs)
+	}
+}
+
+func TestScopeApplyToEventKeepsEventValues(t *testing.T) {
+	scope := NewScope()
+	scope.SetUser("scope-user")
+	scope.SetSession("scope-session")
+	scope.SetCorrelationID("scope-correlation")
+	scope.SetTag("tenant", "scope")
+	scope.SetTag("region", "eu")
+	scope.AddBreadcrumb(Breadcrumb{Message: "scope"})
+
+	event := &AppEvent{
+		EventUser:                    "event-user",
+		EventSession:                 "event-session",
+		ContextCrossAppCorrelationId: "event-correlation",
+		ContextTags:                  []string{"tenant:event"},
+		Breadcrumbs:                  []Breadcrumb{{Message: "event"}},
+	}
+	scope.ApplyToEvent(event)
+
+	if event.EventUser != "event-user" || event.EventSession != "event-session" || event.ContextCrossAppCorrelationId != "event-correlation" {
+		t.Errorf("scope overrode event values: %+v", event)
+	}
+	if !reflect.DeepEqual(event.ContextTags, []string{"tenant:event", "region:eu"}) {
+		t.Errorf("unexpected tags %v", event.ContextTags)
+	}
+	if len(event.Breadcrumbs) != 1 || event.Breadcrumbs[0].Message != "event" {
+		t.Errorf("scope replaced event breadcrumbs: %+v", event.Breadcrumbs)
+	}
+}
+
+func TestMergeCustomDataOnlyFillsEmptySlots(t *testing.T) {
+	dst := CustomData{
+		StringData: CustomStringData{CustomData1: "event", CustomData3: "event"},
+		DoubleData: CustomDoubleData{CustomData2: 1.5},
+	}
+	src := CustomData{
+		StringData: CustomStringData{CustomData1: "scope", CustomData2: "scope", CustomData10: "scope"},
+		DoubleData: CustomDoubleData{CustomData1: 7, CustomData2: 9},
+	}
+	mergeCustomData(&dst, src)
+
+	want := CustomData{
+		StringData: CustomStringData{CustomData1: "event", CustomData2: "scope", CustomData3: "event", CustomData10: "scope"},
+		DoubleData: CustomDoubleData{CustomData1: 7, CustomData2: 1.5},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("expected %+v, got %+v", want, dst)
+	}
+}
+
+func TestZeroValueScope(t *testing.T) {
+	scope := &Scope{}
+	scope.SetTag("tenant", "acme")
+	scope.SetCustomProperties(CustomData{StringData: CustomStringData{CustomData1: "plan"}})
+	scope.AddBreadcrumb(Breadcrumb{Message: "opened"})
+
+	clone := (&Scope{}).Clone()
+	clone.SetTag("empty", "clone")
+
+	appEvent := &AppEvent{}
+	scope.Clone().ApplyToEvent(appEvent)
+	if !reflect.DeepEqual(appEvent.ContextTags, []string{"tenant:acme"}) || len(appEvent.Breadcrumbs) != 1 ||
+		appEvent.CustomProperties.StringData.CustomData1 != "plan" {
+		t.Errorf("unexpected event %+v", appEvent)
+	}
+}
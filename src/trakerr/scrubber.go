@@ -0,0 +1,174 @@
+package trakerr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the values removed by a Scrubber.
+const Redacted = "[REDACTED]"
+
+// DefaultDenyKeys are the keys whose values NewScrubber redacts.
+var DefaultDenyKeys = []string{"password", "passwd", "pwd", "secret", "token", "api_key", "apikey", "access_key", "authorization", "cookie", "session_id"}
+
+var (
+	emailPattern       = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	cardPattern        = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+	bearerPattern      = regexp.MustCompile(`(?i)\b(bearer\s+)[a-z0-9\-._~+/]+=*`)
+	urlPasswordPattern = regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.\-]*://[^:@/\s]*:)[^@/\s]+@`)
+)
+
+// Scrubber removes personal data and secrets from the strings of an AppEvent before it is sent: email
+// addresses, credit card numbers that pass the Luhn check, bearer tokens, passwords in URLs, the values of
+// denied keys and anything matching the extra patterns. The ApiKey field is left alone.
+// Configure a Scrubber before passing it to SetScrubber; it must not be changed afterwards.
+type Scrubber struct {
+
+	// extra patterns whose matches are replaced with Redacted
+	Patterns []*regexp.Regexp
+
+	// keys whose values are redacted, in key=value and key: value text, tags and breadcrumb data (case insensitive)
+	DenyKeys []string
+
+	// when set, EventUser is replaced by its hex HMAC-SHA256 with this key instead of being scrubbed, so the
+	// events of one user can still be grouped without sending who the user is; otherwise EventUser is redacted
+	// entirely when it contains an email address or when "user" or "eventUser" is a denied key
+	UserHMACKey []byte
+
+	once        sync.Once
+	denyPattern *regexp.Regexp
+	denied      map[string]bool
+}
+
+// NewScrubber returns a Scrubber with the DefaultDenyKeys.
+func NewScrubber() *Scrubber {
+	return &Scrubber{DenyKeys: append([]string(nil), DefaultDenyKeys...)}
+}
+
+func (scrubber *Scrubber) init() {
+	scrubber.once.Do(func() {
+		scrubber.denied = make(map[string]bool, len(scrubber.DenyKeys))
+		quoted := make([]string, 0, len(scrubber.DenyKeys))
+		for _, key := range scrubber.DenyKeys {
+			scrubber.denied[strings.ToLower(key)] = true
+			quoted = append(quoted, regexp.QuoteMeta(key))
+		}
+		if len(quoted) > 0 {
+			scrubber.denyPattern = regexp.MustCompile(`(?i)("?\b(?:` + strings.Join(quoted, "|") + `)\b"?\s*[:=]\s*"?)(?:(?:bearer|basic)\s+)?[^"&\s,;]+`)
+		}
+	})
+}
+
+// ScrubString returns text with the personal data and secrets replaced.
+func (scrubber *Scrubber) ScrubString(text string) string {
+	scrubber.init()
+	if text == "" {
+		return text
+	}
+	if scrubber.denyPattern != nil {
+		text = scrubber.denyPattern.ReplaceAllString(text, "${1}"+Redacted)
+	}
+	text = urlPasswordPattern.ReplaceAllString(text, "${1}"+Redacted+"@")
+	text = bearerPattern.ReplaceAllString(text, "${1}"+Redacted)
+	text = emailPattern.ReplaceAllString(text, Redacted)
+	text = cardPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if luhnValid(match) {
+			return Redacted
+		}
+		return match
+	})
+	for _, pattern := range scrubber.Patterns {
+		text = pattern.ReplaceAllString(text, Redacted)
+	}
+	return text
+}
+
+// Scrub replaces the personal data and secrets in every string field of appEvent, including custom data,
+// stack traces, tags and breadcrumbs.
+func (scrubber *Scrubber) Scrub(appEvent *AppEvent) {
+	scrubber.init()
+	if len(scrubber.UserHMACKey) > 0 && appEvent.EventUser != "" {
+		mac := hmac.New(sha256.New, scrubber.UserHMACKey)
+		mac.Write([]byte(appEvent.EventUser))
+		user := hex.EncodeToString(mac.Sum(nil))
+		appEvent.EventUser = ""
+		defer func() { appEvent.EventUser = user }()
+	} else if appEvent.EventUser != "" && (emailPattern.MatchString(appEvent.EventUser) || scrubber.denied["user"] || scrubber.denied["eventuser"]) {
+		appEvent.EventUser = Redacted
+	}
+	apiKey := appEvent.ApiKey
+	scrubber.scrubValue(reflect.ValueOf(appEvent).Elem())
+	appEvent.ApiKey = apiKey
+	for i, tag := range appEvent.ContextTags {
+		if parts := strings.SplitN(tag, ":", 2); len(parts) == 2 && scrubber.denied[strings.ToLower(parts[0])] {
+			appEvent.ContextTags[i] = formatTag(parts[0], Redacted)
+		}
+	}
+}
+
+// scrubValue walks value and scrubs the strings it finds. Map values are redacted entirely when their key is denied.
+func (scrubber *Scrubber) scrubValue(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.String:
+		if value.CanSet() {
+			value.SetString(scrubber.ScrubString(value.String()))
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			scrubber.scrubValue(value.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			scrubber.scrubValue(value.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			scrubber.scrubValue(value.Index(i))
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String || value.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, key := range value.MapKeys() {
+			scrubbed := Redacted
+			if !scrubber.denied[strings.ToLower(key.String())] {
+				scrubbed = scrubber.ScrubString(value.MapIndex(key).String())
+			}
+			value.SetMapIndex(key, reflect.ValueOf(scrubbed).Convert(value.Type().Elem()))
+		}
+	}
+}
+
+// luhnValid reports whether the digits in number pass the Luhn checksum used by payment card numbers.
+func luhnValid(number string) bool {
+	sum := 0
+	digits := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if digits%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// SetScrubber scrubs every event with scrubber right before it is sent. Pass nil to send events as they are.
+func (trakerrClient *TrakerrClient) SetScrubber(scrubber *Scrubber) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.scrubber = scrubber
+}
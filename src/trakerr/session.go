@@ -0,0 +1,284 @@
+package trakerr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Session statuses.
+const (
+	SessionStatusOK       = "ok"
+	SessionStatusErrored  = "errored"
+	SessionStatusCrashed  = "crashed"
+	SessionStatusAbnormal = "abnormal"
+)
+
+// SessionReportEventType is the EventType of the events reporting the sessions that ended, per app version.
+const SessionReportEventType = "SessionReport"
+
+// Tag keys of the session report events.
+const (
+	SessionTotalTagKey         = "session.total"
+	SessionCrashFreeRateTagKey = "session.crash_free_rate"
+)
+
+// sessionStatuses are the statuses counted by the session reports, in the order they are reported.
+var sessionStatuses = []string{SessionStatusOK, SessionStatusErrored, SessionStatusCrashed, SessionStatusAbnormal}
+
+// Session is a period of use of the application, such as a request, a job or the run of a command line tool.
+// A session is errored when an error, such as one passed to SendError, or an error or fatal event is sent in it,
+// and crashed when a Recover or Notify function catches a panic in it. A Session is safe for concurrent use.
+type Session struct {
+	client     *TrakerrClient
+	id         string
+	appVersion string
+
+	mu     sync.Mutex
+	status string
+	errors int
+	ended  bool
+}
+
+type sessionContextKey struct{}
+
+// StartSession starts the client wide session, used by the events whose context carries no session. It replaces
+// the previous client wide session without ending it.
+func (trakerrClient *TrakerrClient) StartSession() *Session {
+	session := trakerrClient.newSession()
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.session = session
+	return session
+}
+
+// StartSessionContext starts a session for the events sent with the returned context, eg. the events of a request.
+func (trakerrClient *TrakerrClient) StartSessionContext(ctx context.Context) (context.Context, *Session) {
+	session := trakerrClient.newSession()
+	return context.WithValue(ctx, sessionContextKey{}, session), session
+}
+
+// EndSession ends the client wide session with status, or the status the session earned when status is "".
+func (trakerrClient *TrakerrClient) EndSession(status string) {
+	trakerrClient.mu.Lock()
+	session := trakerrClient.session
+	trakerrClient.session = nil
+	trakerrClient.mu.Unlock()
+	if session != nil {
+		session.End(status)
+	}
+}
+
+// SessionFromContext returns the session carried by ctx, or nil if there is none.
+func SessionFromContext(ctx context.Context) *Session {
+	if ctx == nil {
+		return nil
+	}
+	session, _ := ctx.Value(sessionContextKey{}).(*Session)
+	return session
+}
+
+func (trakerrClient *TrakerrClient) newSession() *Session {
+	trakerrClient.mu.RLock()
+	appVersion := trakerrClient.contextAppVersion
+	trakerrClient.mu.RUnlock()
+	return &Session{client: trakerrClient, id: randomHex(16), appVersion: appVersion, status: SessionStatusOK}
+}
+
+// sessionFor returns the session of the events sent with ctx: the one ctx carries, or else the client wide one.
+func (trakerrClient *TrakerrClient) sessionFor(ctx context.Context) *Session {
+	if session := SessionFromContext(ctx); session != nil {
+		return session
+	}
+	trakerrClient.mu.RLock()
+	defer trakerrClient.mu.RUnlock()
+	return trakerrClient.session
+}
+
+// trackSession sets the EventSession of appEvent to the session of ctx, unless it has one, and marks the
+// session crashed for a panic and errored for an error or an event at the error or fatal level.
+func (trakerrClient *TrakerrClient) trackSession(ctx context.Context, appEvent *AppEvent, hint Hint) {
+	session := trakerrClient.sessionFor(ctx)
+	if session == nil {
+		return
+	}
+	if appEvent.EventSession == "" {
+		appEvent.EventSession = session.id
+	}
+	if hint.Panic != nil {
+		session.mark(SessionStatusCrashed)
+	} else if hint.Error != nil || appEvent.LogLevel == "error" || appEvent.LogLevel == "fatal" {
+		session.mark(SessionStatusErrored)
+	}
+}
+
+// ID returns the ID sent as the EventSession of the events of the session.
+func (session *Session) ID() string {
+	return session.id
+}
+
+// Status returns the status the session has so far.
+func (session *Session) Status() string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.status
+}
+
+// Errors returns the number of errors and panics sent in the session.
+func (session *Session) Errors() int {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.errors
+}
+
+func (session *Session) mark(status string) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.ended {
+		return
+	}
+	session.errors++
+	if status == SessionStatusCrashed || session.status == SessionStatusOK {
+		session.status = status
+	}
+}
+
+// End ends the session with status, or with the status it earned (ok, errored or crashed) when status is "", and
+// counts it in the next session report. Calling End again does nothing.
+func (session *Session) End(status string) {
+	session.mu.Lock()
+	if session.ended {
+		session.mu.Unlock()
+		return
+	}
+	session.ended = true
+	if status != "" {
+		session.status = status
+	}
+	status = session.status
+	session.mu.Unlock()
+
+	session.client.mu.RLock()
+	aggregator := session.client.sessions
+	session.client.mu.RUnlock()
+	if aggregator != nil {
+		aggregator.count(session.appVersion, status)
+	}
+}
+
+// sessionAggregator counts the sessions that ended per app version and status between two reports.
+type sessionAggregator struct {
+	mu       sync.Mutex
+	interval time.Duration
+	counts   map[string]map[string]int
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func (aggregator *sessionAggregator) count(appVersion string, status string) {
+	aggregator.mu.Lock()
+	defer aggregator.mu.Unlock()
+	if aggregator.counts[appVersion] == nil {
+		aggregator.counts[appVersion] = make(map[string]int)
+	}
+	aggregator.counts[appVersion][status]++
+}
+
+// take returns the counts so far and starts counting from zero.
+func (aggregator *sessionAggregator) take() map[string]map[string]int {
+	aggregator.mu.Lock()
+	defer aggregator.mu.Unlock()
+	counts := aggregator.counts
+	aggregator.counts = make(map[string]map[string]int)
+	return counts
+}
+
+// EnableSessionTracking counts the sessions that end and reports the counts every interval, one
+// SessionReport event per app version with the number of sessions per status and the crash free rate as tags.
+// An interval of 0 turns tracking off after sending the pending counts. Call Close before the program exits
+// to send the last report.
+func (trakerrClient *TrakerrClient) EnableSessionTracking(interval time.Duration) {
+	trakerrClient.mu.Lock()
+	previous := trakerrClient.sessions
+	trakerrClient.sessions = nil
+	if interval > 0 {
+		trakerrClient.sessions = &sessionAggregator{
+			interval: interval,
+			counts:   make(map[string]map[string]int),
+			stop:     make(chan struct{}),
+			done:     make(chan struct{}),
+		}
+		go trakerrClient.reportSessions(trakerrClient.sessions)
+	}
+	trakerrClient.mu.Unlock()
+
+	if previous != nil {
+		trakerrClient.stopSessionTracking(previous)
+	}
+}
+
+// reportSessions sends the session reports of aggregator every interval until it is stopped.
+func (trakerrClient *TrakerrClient) reportSessions(aggregator *sessionAggregator) {
+	defer close(aggregator.done)
+	ticker := time.NewTicker(aggregator.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			trakerrClient.sendSessionReports(aggregator)
+		case <-aggregator.stop:
+			return
+		}
+	}
+}
+
+// stopSessionTracking stops the reporting goroutine of aggregator and sends what it still holds.
+func (trakerrClient *TrakerrClient) stopSessionTracking(aggregator *sessionAggregator) error {
+	close(aggregator.stop)
+	<-aggregator.done
+	return trakerrClient.sendSessionReports(aggregator)
+}
+
+// sendSessionReports sends a report per app version for the sessions counted by aggregator and returns the
+// first error. Reports skip sampling and duplicate suppression, which would skew the rates.
+func (trakerrClient *TrakerrClient) sendSessionReports(aggregator *sessionAggregator) error {
+	counts := aggregator.take()
+	versions := make([]string, 0, len(counts))
+	for version := range counts {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	var firstErr error
+	for _, version := range versions {
+		if _, err := trakerrClient.post(trakerrClient.sessionReport(version, counts[version])); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sessionReport returns the event reporting the sessions of appVersion.
+func (trakerrClient *TrakerrClient) sessionReport(appVersion string, counts map[string]int) *AppEvent {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	tags := map[string]string{SessionTotalTagKey: strconv.Itoa(total)}
+	message := fmt.Sprintf("%d sessions", total)
+	for _, status := range sessionStatuses {
+		tags["session."+status] = strconv.Itoa(counts[status])
+		message += fmt.Sprintf(", %d %s", counts[status], status)
+	}
+	crashFree := float64(total-counts[SessionStatusCrashed]) / float64(total)
+	tags[SessionCrashFreeRateTagKey] = strconv.FormatFloat(crashFree, 'f', 4, 64)
+
+	appEvent := trakerrClient.NewAppEvent("info", "", SessionReportEventType, message)
+	appEvent.ContextAppVersion = appVersion
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, tags)
+	trakerrClient.fillContextTags(appEvent)
+	return appEvent
+}
@@ -0,0 +1,83 @@
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionStatus(t *testing.T) {
+	client, recorder := newTestClient(t)
+
+	ctx, ok := client.StartSessionContext(context.Background())
+	client.SendEventContext(ctx, client.NewAppEvent("info", "", "Login", "user logged in"))
+	if ok.Status() != SessionStatusOK {
+		t.Errorf("an info event changed the status to %q", ok.Status())
+	}
+	if recorder.Events()[0].EventSession != ok.ID() || len(ok.ID()) != 32 {
+		t.Errorf("the event is not in the session: %q", recorder.Events()[0].EventSession)
+	}
+
+	ctx, errored := client.StartSessionContext(context.Background())
+	client.SendErrorContext(ctx, "warning", "", errors.New("retrying"))
+	if errored.Status() != SessionStatusErrored || errored.Errors() != 1 {
+		t.Errorf("SendError left the status %q", errored.Status())
+	}
+
+	crashed := client.StartSession()
+	func() {
+		defer client.Recover("error", "")
+		panic("boom")
+	}()
+	client.SendError("error", "", errors.New("after the crash"))
+	if crashed.Status() != SessionStatusCrashed || crashed.Errors() != 2 {
+		t.Errorf("Recover left the status %q with %d errors", crashed.Status(), crashed.Errors())
+	}
+
+	client.EndSession("")
+	crashed.End(SessionStatusOK)
+	if crashed.Status() != SessionStatusCrashed {
+		t.Error("a session was ended twice")
+	}
+	client.SendError("error", "", errors.New("outside of a session"))
+	if events := recorder.Events(); events[len(events)-1].EventSession != "" {
+		t.Error("the ended client session is still used")
+	}
+}
+
+func TestSessionReports(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetSampleRate(0) // reports are not sampled
+	client.EnableSessionTracking(time.Hour)
+	defer client.Close()
+
+	for _, status := range []string{"", "", "", SessionStatusAbnormal} {
+		_, session := client.StartSessionContext(context.Background())
+		session.End(status)
+	}
+	ctx, crashed := client.StartSessionContext(context.Background())
+	client.sendErrorWithSkipContext(ctx, "boom", true, "fatal", "", 1)
+	crashed.End("")
+
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 report, got %d events", len(events))
+	}
+	report := events[0]
+	if report.EventType != SessionReportEventType || report.ContextAppVersion != "1.0" || report.EventMessage != "5 sessions, 3 ok, 0 errored, 1 crashed, 1 abnormal" {
+		t.Errorf("unexpected report %q %q %q", report.EventType, report.ContextAppVersion, report.EventMessage)
+	}
+	for _, tag := range []string{"session.total:5", "session.ok:3", "session.crashed:1", "session.abnormal:1", "session.crash_free_rate:0.8000"} {
+		if !hasTag(report, tag) {
+			t.Errorf("tag %s missing from %v", tag, report.ContextTags)
+		}
+	}
+
+	if err := client.Flush(); err != nil || len(recorder.Events()) != 1 {
+		t.Error("an empty report was sent")
+	}
+}
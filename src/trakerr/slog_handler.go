@@ -0,0 +1,277 @@
+//go:build go1.21
+// +build go1.21
+
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// SlogHandlerOptions configures a SlogHandler. Zero values use the defaults.
+type SlogHandlerOptions struct {
+
+	// records at or above this level are sent to Trakerr as events, defaults to slog.LevelError
+	Level slog.Leveler
+
+	// records below Level but at or above this one are recorded as breadcrumbs on the trail of the context they
+	// are logged with, defaults to slog.LevelInfo
+	BreadcrumbLevel slog.Leveler
+
+	// EventType of records without an error attribute, defaults to "slog"
+	EventType string
+
+	// Classification of the events, defaults to the client default
+	Classification string
+
+	// attributes whose values go to CustomProperties.StringData.CustomData1, CustomData2, ... in order;
+	// every attribute is also sent as a tag
+	StringDataKeys []string
+
+	// numeric attributes whose values go to CustomProperties.DoubleData.CustomData1, CustomData2, ... in order
+	DoubleDataKeys []string
+}
+
+// SlogHandler is a slog.Handler that passes records on to another handler and sends the records at or above
+// a level to Trakerr. The attributes become tags, error attributes become the stack traces of the event, and
+// the lower level records are added to the breadcrumb trail of their context, so the events sent with the same
+// context, such as those of a request, carry them.
+type SlogHandler struct {
+	client  *TrakerrClient
+	next    slog.Handler
+	options SlogHandlerOptions
+	attrs   []slogField
+	group   string
+}
+
+// slogField is an attribute flattened to its dotted key, eg. "request.method".
+type slogField struct {
+	key   string
+	value slog.Value
+}
+
+// NewSlogHandler returns a handler sending records to client and passing them on to next, which may be nil.
+func NewSlogHandler(client *TrakerrClient, next slog.Handler, options *SlogHandlerOptions) *SlogHandler {
+	handler := &SlogHandler{client: client, next: next}
+	if options != nil {
+		handler.options = *options
+	}
+	if handler.options.Level == nil {
+		handler.options.Level = slog.LevelError
+	}
+	if handler.options.BreadcrumbLevel == nil {
+		handler.options.BreadcrumbLevel = slog.LevelInfo
+	}
+	if handler.options.EventType == "" {
+		handler.options.EventType = "slog"
+	}
+	return handler
+}
+
+// slogLevel maps a slog level to a Trakerr log level.
+func slogLevel(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warning"
+	case level < slog.LevelError+4:
+		return "error"
+	}
+	return "fatal"
+}
+
+// Enabled implements slog.Handler.
+func (handler *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level >= handler.options.Level.Level() || level >= handler.options.BreadcrumbLevel.Level() {
+		return true
+	}
+	return handler.next != nil && handler.next.Enabled(ctx, level)
+}
+
+// WithAttrs implements slog.Handler.
+func (handler *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *handler
+	clone.attrs = handler.attrs[:len(handler.attrs):len(handler.attrs)]
+	for _, attr := range attrs {
+		clone.attrs = appendSlogAttr(clone.attrs, handler.group, attr)
+	}
+	if handler.next != nil {
+		clone.next = handler.next.WithAttrs(attrs)
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler.
+func (handler *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return handler
+	}
+	clone := *handler
+	clone.group = handler.group + name + "."
+	if handler.next != nil {
+		clone.next = handler.next.WithGroup(name)
+	}
+	return &clone
+}
+
+// Handle implements slog.Handler.
+func (handler *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	var nextErr error
+	if handler.next != nil && handler.next.Enabled(ctx, record.Level) {
+		nextErr = handler.next.Handle(ctx, record)
+	}
+
+	fields := handler.attrs[:len(handler.attrs):len(handler.attrs)]
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, handler.group, attr)
+		return true
+	})
+
+	if record.Level < handler.options.Level.Level() {
+		if record.Level >= handler.options.BreadcrumbLevel.Level() {
+			handler.client.AddBreadcrumbContext(ctx, handler.breadcrumb(record, fields))
+		}
+		return nextErr
+	}
+
+	_, err := handler.client.sendContext(ctx, handler.event(record, fields), Hint{Error: firstSlogError(fields)})
+	if nextErr != nil {
+		return nextErr
+	}
+	if errors.Is(err, ErrEventDropped) {
+		return nil
+	}
+	return err
+}
+
+// event converts a record at or above the event level into an AppEvent.
+func (handler *SlogHandler) event(record slog.Record, fields []slogField) *AppEvent {
+	eventType := handler.options.EventType
+	var stacktrace []InnerStackTrace
+	var traceLines []StackTraceLine
+	for _, field := range fields {
+		err, ok := field.value.Any().(error)
+		if !ok || field.value.Kind() != slog.KindAny {
+			continue
+		}
+		if stacktrace == nil {
+			eventType = fmt.Sprintf("%T", err)
+			traceLines = handler.client.eventTraceBuilder.GetTraceLinesFromPCs(callersFrom(record.PC), 50)
+		}
+		stacktrace = append(stacktrace, InnerStackTrace{Type_: fmt.Sprintf("%T", err), Message: err.Error(), TraceLines: traceLines})
+	}
+
+	appEvent := handler.client.NewAppEvent(slogLevel(record.Level), handler.options.Classification, eventType, record.Message)
+	appEvent.EventStacktrace = stacktrace
+	if !record.Time.IsZero() {
+		appEvent.EventTime = record.Time.UnixNano() / int64(time.Millisecond)
+	}
+
+	tags := make(map[string]string)
+	for _, field := range fields {
+		if field.value.Kind() == slog.KindAny {
+			if _, ok := field.value.Any().(error); ok {
+				continue
+			}
+		}
+		tags[field.key] = field.value.String()
+		setSlogCustomData(&appEvent.CustomProperties, handler.options, field)
+	}
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, tags)
+	return appEvent
+}
+
+// breadcrumb converts a record below the event level into a breadcrumb.
+func (handler *SlogHandler) breadcrumb(record slog.Record, fields []slogField) Breadcrumb {
+	crumb := Breadcrumb{Category: BreadcrumbCategoryLog, Level: breadcrumbLevel(slogLevel(record.Level)), Message: record.Message}
+	if !record.Time.IsZero() {
+		crumb.Timestamp = record.Time.UnixNano() / int64(time.Millisecond)
+	}
+	if len(fields) > 0 {
+		crumb.Data = make(map[string]string, len(fields))
+		for _, field := range fields {
+			crumb.Data[field.key] = field.value.String()
+		}
+	}
+	return crumb
+}
+
+// appendSlogAttr appends attr, resolved and with groups flattened into dotted keys, to fields.
+func appendSlogAttr(fields []slogField, prefix string, attr slog.Attr) []slogField {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			fields = appendSlogAttr(fields, prefix, member)
+		}
+		return fields
+	}
+	return append(fields, slogField{key: prefix + attr.Key, value: attr.Value})
+}
+
+func firstSlogError(fields []slogField) error {
+	for _, field := range fields {
+		if field.value.Kind() == slog.KindAny {
+			if err, ok := field.value.Any().(error); ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setSlogCustomData copies field to the custom data slot its key is configured for, if any.
+func setSlogCustomData(customData *CustomData, options SlogHandlerOptions, field slogField) {
+	for i, key := range options.StringDataKeys {
+		if key == field.key && i < 10 {
+			customData.SetString(i+1, field.value.String())
+		}
+	}
+	for i, key := range options.DoubleDataKeys {
+		if key != field.key || i >= 10 {
+			continue
+		}
+		var number float64
+		switch field.value.Kind() {
+		case slog.KindFloat64:
+			number = field.value.Float64()
+		case slog.KindInt64:
+			number = float64(field.value.Int64())
+		case slog.KindUint64:
+			number = float64(field.value.Uint64())
+		case slog.KindDuration:
+			number = float64(field.value.Duration()) / float64(time.Millisecond)
+		default:
+			continue
+		}
+		customData.SetDouble(i+1, number)
+	}
+}
+
+// callersFrom returns the stack of the current goroutine from the frame of pc, the call site slog recorded.
+// When the record is handled on another goroutine the call site is all there is.
+func callersFrom(pc uintptr) []uintptr {
+	if pc == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	for i, caller := range pcs {
+		if caller == pc {
+			return pcs[i:]
+		}
+	}
+	return []uintptr{pc}
+}
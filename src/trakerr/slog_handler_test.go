@@ -0,0 +1,109 @@
+//go:build go1.21
+// +build go1.21
+
+package trakerr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandlerSendsErrors(t *testing.T) {
+	client, recorder := newTestClient(t)
+	var output bytes.Buffer
+	handler := NewSlogHandler(client, slog.NewTextHandler(&output, nil), &SlogHandlerOptions{
+		StringDataKeys: []string{"request.method"},
+		DoubleDataKeys: []string{"request.duration"},
+	})
+	logger := slog.New(handler).With("service", "billing").WithGroup("request")
+
+	logger.Debug("not kept")
+	logger.Info("charging card", "attempt", 1)
+	logger.Warn("card declined once")
+	logger.Error("charge failed", "method", "POST", "duration", 1500*time.Millisecond, "err", errors.New("gateway timeout"))
+
+	if !strings.Contains(output.String(), "charge failed") || !strings.Contains(output.String(), "charging card") {
+		t.Errorf("records weren't passed on to the wrapped handler:\n%s", output.String())
+	}
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.LogLevel != "error" || event.EventType != "*errors.errorString" || event.EventMessage != "charge failed" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if len(event.EventStacktrace) != 1 || event.EventStacktrace[0].Message != "gateway timeout" {
+		t.Fatalf("unexpected stack trace %+v", event.EventStacktrace)
+	}
+	if top := event.EventStacktrace[0].TraceLines[0]; !strings.HasSuffix(top.Function, "TestSlogHandlerSendsErrors") {
+		t.Errorf("expected the stack trace to start at the log call, got %+v", top)
+	}
+	wantTags := []string{"request.duration:1.5s", "request.method:POST", "service:billing"}
+	if !reflect.DeepEqual(event.ContextTags, wantTags) {
+		t.Errorf("expected tags %v, got %v", wantTags, event.ContextTags)
+	}
+	if event.CustomProperties.StringData.CustomData1 != "POST" || event.CustomProperties.DoubleData.CustomData1 != 1500 {
+		t.Errorf("unexpected custom properties %+v", event.CustomProperties)
+	}
+	if len(event.Breadcrumbs) != 2 || event.Breadcrumbs[0].Message != "charging card" ||
+		event.Breadcrumbs[0].Data["request.attempt"] != "1" || event.Breadcrumbs[1].Level != "warning" {
+		t.Errorf("unexpected breadcrumbs %+v", event.Breadcrumbs)
+	}
+
+	logger.Error("second failure")
+	if events := recorder.Events(); len(events) != 2 || len(events[1].Breadcrumbs) != 2 || events[1].EventType != "slog" {
+		t.Errorf("expected the trail on the second event too, got %+v", events[1])
+	}
+}
+
+func TestSlogHandlerBreadcrumbsFollowTheContext(t *testing.T) {
+	client, recorder := newTestClient(t)
+	logger := slog.New(NewSlogHandler(client, nil, nil))
+
+	first := WithBreadcrumbs(context.Background(), 10)
+	second := WithBreadcrumbs(context.Background(), 10)
+	client.AddHTTPBreadcrumb(first, "GET", "https://example.com/cart", 200, time.Millisecond)
+	logger.InfoContext(first, "loading cart")
+	logger.InfoContext(second, "another request")
+	logger.ErrorContext(first, "checkout failed")
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	crumbs := events[0].Breadcrumbs
+	if len(crumbs) != 2 || crumbs[0].Category != BreadcrumbCategoryHTTP || crumbs[1].Message != "loading cart" {
+		t.Errorf("expected the HTTP and log breadcrumbs of the request only, got %+v", crumbs)
+	}
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	for level, want := range map[slog.Level]string{
+		slog.LevelDebug:     "debug",
+		slog.LevelInfo:      "info",
+		slog.LevelWarn:      "warning",
+		slog.LevelError:     "error",
+		slog.LevelError + 4: "fatal",
+	} {
+		if got := slogLevel(level); got != want {
+			t.Errorf("slogLevel(%v) = %q, want %q", level, got, want)
+		}
+	}
+
+	client, recorder := newTestClient(t)
+	handler := NewSlogHandler(client, nil, &SlogHandlerOptions{Level: slog.LevelWarn})
+	if handler.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug records are neither events nor breadcrumbs")
+	}
+	slog.New(handler).Warn("disk almost full")
+	if events := recorder.Events(); len(events) != 1 || events[0].LogLevel != "warning" {
+		t.Errorf("expected a warning event, got %+v", events)
+	}
+}
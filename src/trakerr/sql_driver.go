@@ -0,0 +1,365 @@
+package trakerr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Tag keys of the query context added to the events reported by the SQL driver wrapper.
+const (
+	DBSystemTagKey    = "db.system"
+	DBStatementTagKey = "db.statement"
+	DBOperationTagKey = "db.operation"
+)
+
+// sqlLiteralPattern matches the parts of a query NormalizeSQL rewrites: comments, string literals, numbered
+// placeholders (kept), numbers and runs of white space.
+var sqlLiteralPattern = regexp.MustCompile(`--[^\n]*|/\*(?s:.*?)\*/|'(?:[^']|'')*'|\$\d+|(?i)\b(?:0x[0-9a-f]+|\d+(?:\.\d+)?(?:e[+-]?\d+)?)\b|\s+`)
+
+// sqlInListPattern matches a list of two or more placeholders, eg. "(?, ?, ?)".
+var sqlInListPattern = regexp.MustCompile(`\(\?(?:\s*,\s*\?)+\)`)
+
+// NormalizeSQL strips the literals from query so the same statement with different values normalizes to the same
+// text: string and number literals become ?, lists of them become (?), comments are removed and white space is
+// collapsed. "SELECT * FROM users WHERE id IN (1, 2) AND name = 'bob'" becomes
+// "SELECT * FROM users WHERE id IN (?) AND name = ?".
+func NormalizeSQL(query string) string {
+	query = sqlLiteralPattern.ReplaceAllStringFunc(query, func(match string) string {
+		switch {
+		case strings.HasPrefix(match, "--"), strings.HasPrefix(match, "/*"):
+			return " "
+		case strings.TrimSpace(match) == "":
+			return " "
+		case match[0] == '$':
+			return match
+		}
+		return "?"
+	})
+	query = sqlInListPattern.ReplaceAllString(query, "(?)")
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// sqlOperation returns the first keyword of a normalized query, eg. "SELECT".
+func sqlOperation(query string) string {
+	if space := strings.IndexByte(query, ' '); space > 0 {
+		query = query[:space]
+	}
+	return strings.ToUpper(strings.TrimLeft(query, "("))
+}
+
+// SQLDriverOptions configures WrapSQLDriver and OpenSQL. Zero values use the defaults.
+type SQLDriverOptions struct {
+
+	// queries that take at least this long are reported as "SlowQuery" events, zero disables them
+	SlowQueryThreshold time.Duration
+
+	// log level of failed queries, defaults to "error"
+	LogLevel string
+
+	// log level of slow queries, defaults to "warning"
+	SlowQueryLogLevel string
+
+	// Classification of the events, defaults to the client default
+	Classification string
+}
+
+// WrapSQLDriver returns a driver that delegates to next and reports failed queries to Trakerr with the normalized
+// SQL, the duration and driverName, which is the name next is registered with. Every query is recorded as an SQL
+// breadcrumb on the trail of its context. Register the result under a new name to use it with sql.Open:
+//
+//	db, _ := sql.Open("postgres", dsn)
+//	sql.Register("postgres+trakerr", client.WrapSQLDriver("postgres", db.Driver(), nil))
+func (trakerrClient *TrakerrClient) WrapSQLDriver(driverName string, next driver.Driver, options *SQLDriverOptions) driver.Driver {
+	wrapped := &sqlDriver{client: trakerrClient, name: driverName, next: next}
+	if options != nil {
+		wrapped.options = *options
+	}
+	if wrapped.options.LogLevel == "" {
+		wrapped.options.LogLevel = "error"
+	}
+	if wrapped.options.SlowQueryLogLevel == "" {
+		wrapped.options.SlowQueryLogLevel = "warning"
+	}
+	return wrapped
+}
+
+// OpenSQL opens a database like sql.Open, with the driver registered as driverName wrapped by WrapSQLDriver.
+func (trakerrClient *TrakerrClient) OpenSQL(driverName string, dataSourceName string, options *SQLDriverOptions) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	next := db.Driver()
+	db.Close()
+
+	connector, err := trakerrClient.WrapSQLDriver(driverName, next, options).(driver.DriverContext).OpenConnector(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+type sqlDriver struct {
+	client  *TrakerrClient
+	name    string
+	next    driver.Driver
+	options SQLDriverOptions
+}
+
+func (wrapped *sqlDriver) Open(name string) (driver.Conn, error) {
+	conn, err := wrapped.next.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{driver: wrapped, next: conn}, nil
+}
+
+func (wrapped *sqlDriver) OpenConnector(name string) (driver.Connector, error) {
+	if driverContext, ok := wrapped.next.(driver.DriverContext); ok {
+		connector, err := driverContext.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlConnector{driver: wrapped, next: connector}, nil
+	}
+	return &sqlConnector{driver: wrapped, name: name}, nil
+}
+
+// report records a query as a breadcrumb and sends it to Trakerr if it failed or was slow.
+func (wrapped *sqlDriver) report(ctx context.Context, query string, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	duration := time.Since(start)
+	normalized := NormalizeSQL(query)
+	wrapped.client.AddSQLBreadcrumb(ctx, normalized, duration, err)
+
+	var appEvent *AppEvent
+	hint := Hint{}
+	if err != nil && !errors.Is(err, driver.ErrBadConn) {
+		// the stack starts at the database/sql call, below report and the wrapper method
+		appEvent = wrapped.client.createAppEventFromErrorWithSkipContext(ctx, err, wrapped.options.LogLevel, wrapped.options.Classification, 2)
+		hint.Error = err
+	} else if err == nil && wrapped.options.SlowQueryThreshold > 0 && duration >= wrapped.options.SlowQueryThreshold {
+		message := fmt.Sprintf("slow query (%s): %s", duration.Round(time.Millisecond), normalized)
+		appEvent = wrapped.client.NewAppEvent(wrapped.options.SlowQueryLogLevel, wrapped.options.Classification, "SlowQuery", message)
+	} else {
+		return
+	}
+
+	appEvent.ContextOperationTimeMillis = int64(duration / time.Millisecond)
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, map[string]string{
+		DBSystemTagKey:    wrapped.name,
+		DBStatementTagKey: normalized,
+		DBOperationTagKey: sqlOperation(normalized),
+	})
+	wrapped.client.sendContext(ctx, appEvent, hint)
+}
+
+type sqlConnector struct {
+	driver *sqlDriver
+	next   driver.Connector
+	name   string
+}
+
+func (connector *sqlConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if connector.next == nil {
+		return connector.driver.Open(connector.name)
+	}
+	conn, err := connector.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlConn{driver: connector.driver, next: conn}, nil
+}
+
+func (connector *sqlConnector) Driver() driver.Driver {
+	return connector.driver
+}
+
+// sqlConn wraps a driver.Conn. The optional interfaces it implements fall back to what database/sql does when
+// the wrapped connection doesn't implement them.
+type sqlConn struct {
+	driver *sqlDriver
+	next   driver.Conn
+}
+
+func (conn *sqlConn) Prepare(query string) (driver.Stmt, error) {
+	return conn.PrepareContext(context.Background(), query)
+}
+
+func (conn *sqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := conn.next.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.next.Prepare(query)
+	}
+	if err != nil {
+		conn.driver.report(ctx, query, start, err)
+		return nil, err
+	}
+	return &sqlStmt{driver: conn.driver, next: stmt, query: query}, nil
+}
+
+func (conn *sqlConn) Close() error {
+	return conn.next.Close()
+}
+
+func (conn *sqlConn) Begin() (driver.Tx, error) {
+	return conn.next.Begin()
+}
+
+func (conn *sqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := conn.next.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("trakerr: the wrapped SQL driver does not support transaction options")
+	}
+	return conn.next.Begin()
+}
+
+func (conn *sqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := conn.next.(driver.ExecerContext); ok {
+		result, err = execer.ExecContext(ctx, query, args)
+	} else if execer, ok := conn.next.(driver.Execer); ok {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			result, err = execer.Exec(query, values)
+		}
+	} else {
+		return nil, driver.ErrSkip
+	}
+	conn.driver.report(ctx, query, start, err)
+	return result, err
+}
+
+func (conn *sqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := conn.next.(driver.QueryerContext); ok {
+		rows, err = queryer.QueryContext(ctx, query, args)
+	} else if queryer, ok := conn.next.(driver.Queryer); ok {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = queryer.Query(query, values)
+		}
+	} else {
+		return nil, driver.ErrSkip
+	}
+	conn.driver.report(ctx, query, start, err)
+	return rows, err
+}
+
+func (conn *sqlConn) Ping(ctx context.Context) error {
+	if pinger, ok := conn.next.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (conn *sqlConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := conn.next.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (conn *sqlConn) IsValid() bool {
+	if validator, ok := conn.next.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (conn *sqlConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := conn.next.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+type sqlStmt struct {
+	driver *sqlDriver
+	next   driver.Stmt
+	query  string
+}
+
+func (stmt *sqlStmt) Close() error {
+	return stmt.next.Close()
+}
+
+func (stmt *sqlStmt) NumInput() int {
+	return stmt.next.NumInput()
+}
+
+func (stmt *sqlStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	result, err := stmt.next.Exec(args)
+	stmt.driver.report(context.Background(), stmt.query, start, err)
+	return result, err
+}
+
+func (stmt *sqlStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := stmt.next.Query(args)
+	stmt.driver.report(context.Background(), stmt.query, start, err)
+	return rows, err
+}
+
+func (stmt *sqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := stmt.next.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			result, err = stmt.next.Exec(values)
+		}
+	}
+	stmt.driver.report(ctx, stmt.query, start, err)
+	return result, err
+}
+
+func (stmt *sqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := stmt.next.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = stmt.next.Query(values)
+		}
+	}
+	stmt.driver.report(ctx, stmt.query, start, err)
+	return rows, err
+}
+
+// namedValues converts the arguments for the context-less driver methods, which don't take names.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("trakerr: the wrapped SQL driver does not support named arguments")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,201 @@
+package trakerr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDriver is a database/sql driver whose queries fail when they contain "missing", take 20ms when they
+// contain "pg_sleep" and can't be prepared when they contain "syntax".
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(query, "syntax") {
+		return nil, errors.New("syntax error at or near \"SELEC\"")
+	}
+	return fakeStmt{query: query}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return fakeQuery(query)
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+func (stmt fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if _, err := fakeQuery(stmt.query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (stmt fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return fakeQuery(stmt.query)
+}
+
+func fakeQuery(query string) (driver.Rows, error) {
+	if strings.Contains(query, "missing") {
+		return nil, errors.New("relation \"missing\" does not exist")
+	}
+	if strings.Contains(query, "pg_sleep") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (fakeRows) Close() error {
+	return nil
+}
+
+func (fakeRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+func init() {
+	sql.Register("trakerrfake", fakeDriver{})
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM users WHERE id IN (1, 2, 3) AND name = 'o''brien'":      "SELECT * FROM users WHERE id IN (?) AND name = ?",
+		"select  *\n\tfrom table1 -- find the row\nwhere x = $1 and y > 2.5e3": "select * from table1 where x = $1 and y > ?",
+		"UPDATE t /* batch */ SET flag = 0x1F WHERE id = ?":                    "UPDATE t SET flag = ? WHERE id = ?",
+	}
+	for query, want := range tests {
+		if got := NormalizeSQL(query); got != want {
+			t.Errorf("NormalizeSQL(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestSQLDriverReportsFailedQueries(t *testing.T) {
+	client, recorder := newTestClient(t)
+	db, err := client.OpenSQL("trakerrfake", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx, scope := WithScope(context.Background())
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users WHERE name = 'bob'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if _, err := db.QueryContext(ctx, "SELECT * FROM missing WHERE id = 42"); err == nil {
+		t.Fatal("expected the query to fail")
+	}
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventMessage != "relation \"missing\" does not exist" || event.LogLevel != "error" {
+		t.Errorf("unexpected event %q %q", event.EventMessage, event.LogLevel)
+	}
+	for _, tag := range []string{"db.system:trakerrfake", "db.statement:SELECT * FROM missing WHERE id = ?", "db.operation:SELECT"} {
+		if !hasTag(event, tag) {
+			t.Errorf("tag %s missing from %v", tag, event.ContextTags)
+		}
+	}
+	if crumbs := scope.Breadcrumbs(); len(crumbs) != 2 || crumbs[0].Message != "SELECT id FROM users WHERE name = ?" || crumbs[1].Level != "error" {
+		t.Errorf("unexpected breadcrumbs %+v", crumbs)
+	}
+}
+
+func TestSQLDriverReportsPreparedStatements(t *testing.T) {
+	client, recorder := newTestClient(t)
+	db, err := client.OpenSQL("trakerrfake", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Prepare("SELEC syntax"); err == nil {
+		t.Fatal("expected the prepare to fail")
+	}
+	stmt, err := db.Prepare("DELETE FROM missing WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(7); err == nil {
+		t.Fatal("expected the statement to fail")
+	}
+
+	events := recorder.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if !hasTag(events[0], "db.statement:SELEC syntax") || !hasTag(events[1], "db.operation:DELETE") {
+		t.Errorf("unexpected events %v %v", events[0].ContextTags, events[1].ContextTags)
+	}
+}
+
+func TestSQLDriverReportsSlowQueries(t *testing.T) {
+	client, recorder := newTestClient(t)
+	wrapped := client.WrapSQLDriver("trakerrfake", fakeDriver{}, &SQLDriverOptions{SlowQueryThreshold: 10 * time.Millisecond})
+	connector, err := wrapped.(driver.DriverContext).OpenConnector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	for _, query := range []string{"SELECT 1", "SELECT pg_sleep(0.02)"} {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventType != "SlowQuery" || event.LogLevel != "warning" || !strings.HasSuffix(event.EventMessage, "): SELECT pg_sleep(?)") {
+		t.Errorf("unexpected event %q %q %q", event.EventType, event.LogLevel, event.EventMessage)
+	}
+	if event.ContextOperationTimeMillis < 10 {
+		t.Errorf("expected the duration, got %dms", event.ContextOperationTimeMillis)
+	}
+}
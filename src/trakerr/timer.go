@@ -0,0 +1,164 @@
+package trakerr
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TimerTagKey is the tag key of the dotted name of the timer a Measure event was reported by, eg. "checkout.charge".
+const TimerTagKey = "timer"
+
+// Timer measures how long an operation takes. Timers started with StartTimerContext nest: a timer started with
+// the context of another is its child. A Timer is safe for concurrent use.
+type Timer struct {
+	client *TrakerrClient
+	name   string
+	parent *Timer
+	start  time.Time
+
+	mu       sync.Mutex
+	stopped  bool
+	duration time.Duration
+}
+
+type timerContextKey struct{}
+
+// StartTimer starts a timer that isn't part of a request.
+func (trakerrClient *TrakerrClient) StartTimer(name string) *Timer {
+	return &Timer{client: trakerrClient, name: name, start: time.Now()}
+}
+
+// StartTimerContext starts a timer as a child of the timer carried by ctx, if any, and returns a context
+// carrying the new timer. The events sent with the returned context record the duration of the timer and its
+// parents so far in the double slots SetTimerSlot assigned to their names.
+func (trakerrClient *TrakerrClient) StartTimerContext(ctx context.Context, name string) (context.Context, *Timer) {
+	timer := trakerrClient.StartTimer(name)
+	timer.parent = TimerFromContext(ctx)
+	return context.WithValue(ctx, timerContextKey{}, timer), timer
+}
+
+// TimerFromContext returns the timer carried by ctx, or nil if there is none.
+func TimerFromContext(ctx context.Context) *Timer {
+	if ctx == nil {
+		return nil
+	}
+	timer, _ := ctx.Value(timerContextKey{}).(*Timer)
+	return timer
+}
+
+// SetTimerSlot records the durations of the timers called name, in milliseconds, in
+// CustomProperties.DoubleData.CustomData<slot>. slot is 1 to 10; 0 removes the assignment.
+func (trakerrClient *TrakerrClient) SetTimerSlot(name string, slot int) {
+	if slot < 0 || slot > 10 {
+		panic("trakerr: timer slot " + strconv.Itoa(slot) + " is out of range, use 1 to 10")
+	}
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	slots := make(map[string]int, len(trakerrClient.timerSlots)+1)
+	for key, value := range trakerrClient.timerSlots {
+		slots[key] = value
+	}
+	if slot == 0 {
+		delete(slots, name)
+	} else {
+		slots[name] = slot
+	}
+	trakerrClient.timerSlots = slots
+}
+
+func (trakerrClient *TrakerrClient) timerSlot(name string) int {
+	trakerrClient.mu.RLock()
+	defer trakerrClient.mu.RUnlock()
+	return trakerrClient.timerSlots[name]
+}
+
+// Name returns the name the timer was started with.
+func (timer *Timer) Name() string {
+	return timer.name
+}
+
+// Path returns the names of the timer and its parents joined by dots, outermost first, eg. "checkout.charge".
+func (timer *Timer) Path() string {
+	if timer.parent == nil {
+		return timer.name
+	}
+	return timer.parent.Path() + "." + timer.name
+}
+
+// Parent returns the timer this one was started in, or nil.
+func (timer *Timer) Parent() *Timer {
+	return timer.parent
+}
+
+// Stop stops the timer and returns its duration. Calling Stop again returns the same duration.
+func (timer *Timer) Stop() time.Duration {
+	timer.mu.Lock()
+	defer timer.mu.Unlock()
+	if !timer.stopped {
+		timer.stopped = true
+		timer.duration = time.Since(timer.start)
+	}
+	return timer.duration
+}
+
+// Duration returns the duration of a stopped timer, or the time elapsed so far.
+func (timer *Timer) Duration() time.Duration {
+	timer.mu.Lock()
+	defer timer.mu.Unlock()
+	if timer.stopped {
+		return timer.duration
+	}
+	return time.Since(timer.start)
+}
+
+// ApplyToEvent records the duration of the timer on appEvent: in ContextOperationTimeMillis, and in the double
+// slot assigned to its name with SetTimerSlot.
+func (timer *Timer) ApplyToEvent(appEvent *AppEvent) {
+	duration := timer.Duration()
+	appEvent.ContextOperationTimeMillis = int64(duration / time.Millisecond)
+	if slot := timer.client.timerSlot(timer.name); slot != 0 {
+		appEvent.CustomProperties.SetDouble(slot, durationMillis(duration))
+	}
+}
+
+// Measure times fn with a timer started in ctx, as StartTimerContext does. When fn returns an error, the error is
+// sent to Trakerr with the duration and the TimerTagKey tag, and returned.
+func (trakerrClient *TrakerrClient) Measure(ctx context.Context, name string, fn func() error) error {
+	ctx, timer := trakerrClient.StartTimerContext(ctx, name)
+	err := fn()
+	timer.Stop()
+	if err == nil {
+		return nil
+	}
+
+	appEvent := trakerrClient.createAppEventFromErrorWithSkipContext(ctx, err, "error", "", 1)
+	appEvent.EventMessage = fmt.Sprintf("%s: %v", name, err)
+	timer.ApplyToEvent(appEvent)
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, map[string]string{TimerTagKey: timer.Path()})
+	trakerrClient.sendContext(ctx, appEvent, newHint(err, false))
+	return err
+}
+
+// applyTimers records the durations of the timers carried by ctx in their slots of appEvent, an inner timer
+// winning over an outer one with the same slot. Slots already set on the event are left alone.
+func (trakerrClient *TrakerrClient) applyTimers(ctx context.Context, appEvent *AppEvent) {
+	var timers []*Timer
+	for timer := TimerFromContext(ctx); timer != nil; timer = timer.parent {
+		timers = append(timers, timer)
+	}
+	durations := CustomData{}
+	for i := len(timers) - 1; i >= 0; i-- {
+		if slot := trakerrClient.timerSlot(timers[i].name); slot != 0 {
+			durations.SetDouble(slot, durationMillis(timers[i].Duration()))
+		}
+	}
+	mergeCustomData(&appEvent.CustomProperties, durations)
+}
+
+// durationMillis returns duration in fractional milliseconds.
+func durationMillis(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
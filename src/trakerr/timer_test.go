@@ -0,0 +1,88 @@
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimerStop(t *testing.T) {
+	client, _ := newTestClient(t)
+	timer := client.StartTimer("job")
+	time.Sleep(5 * time.Millisecond)
+	duration := timer.Stop()
+	if duration < 5*time.Millisecond {
+		t.Errorf("timer stopped after %s", duration)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if timer.Stop() != duration || timer.Duration() != duration {
+		t.Error("a stopped timer kept running")
+	}
+}
+
+func TestNestedTimersRecordSlotsOnEvents(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetTimerSlot("request", 1)
+	client.SetTimerSlot("db", 2)
+
+	ctx, scope := WithScope(context.Background())
+	ctx, request := client.StartTimerContext(ctx, "request")
+	dbCtx, db := client.StartTimerContext(ctx, "db")
+	otherCtx, other := client.StartTimerContext(ctx, "db")
+	time.Sleep(3 * time.Millisecond)
+	db.Stop()
+	time.Sleep(3 * time.Millisecond)
+	other.Stop()
+	request.Stop()
+
+	if db.Parent() != request || db.Path() != "request.db" || TimerFromContext(dbCtx) != db {
+		t.Errorf("unexpected nesting %q", db.Path())
+	}
+	for _, ctx := range []context.Context{dbCtx, otherCtx, ctx} {
+		if _, err := client.SendEventContext(ctx, client.NewAppEvent("info", "", "Request", "done")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events := recorder.Events()
+	first, second, parent := events[0].CustomProperties.DoubleData, events[1].CustomProperties.DoubleData, events[2].CustomProperties.DoubleData
+	if first.CustomData2 < 3 || second.CustomData2 < first.CustomData2+3 || first.CustomData1 < second.CustomData2 {
+		t.Errorf("unexpected timer slots %+v and %+v", first, second)
+	}
+	if parent.CustomData1 != first.CustomData1 || parent.CustomData2 != 0 {
+		t.Errorf("the timers of other contexts leaked into the event: %+v", parent)
+	}
+	if scope.customProperties != (CustomData{}) {
+		t.Errorf("the timers changed the scope: %+v", scope.customProperties)
+	}
+}
+
+func TestMeasureReportsFailures(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetTimerSlot("charge", 3)
+	ctx, _ := client.StartTimerContext(context.Background(), "checkout")
+
+	if err := client.Measure(ctx, "charge", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	declined := errors.New("card declined")
+	err := client.Measure(ctx, "charge", func() error {
+		time.Sleep(2 * time.Millisecond)
+		return declined
+	})
+	if err != declined {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventMessage != "charge: card declined" || !hasTag(event, "timer:checkout.charge") {
+		t.Errorf("unexpected event %q %v", event.EventMessage, event.ContextTags)
+	}
+	if event.ContextOperationTimeMillis < 2 || event.CustomProperties.DoubleData.CustomData3 < 2 {
+		t.Errorf("duration missing: %d %v", event.ContextOperationTimeMillis, event.CustomProperties.DoubleData.CustomData3)
+	}
+}
@@ -0,0 +1,134 @@
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// W3C trace context headers.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// Default string slots the trace and span IDs are attached to, see SetTraceSlots.
+const (
+	DefaultTraceIDSlot = 9
+	DefaultSpanIDSlot  = 10
+)
+
+// ErrInvalidTraceparent is returned by ParseTraceparent for values that aren't a valid traceparent header.
+var ErrInvalidTraceparent = errors.New("trakerr: invalid traceparent")
+
+// TraceContext is the W3C trace context propagated between services in the traceparent and tracestate headers.
+type TraceContext struct {
+
+	// 32 lowercase hex digits
+	TraceID string
+
+	// ID of the span in the calling service, 16 lowercase hex digits
+	SpanID string
+
+	// whether the caller records the trace
+	Sampled bool
+
+	// vendor specific trace data, passed on unchanged
+	TraceState string
+}
+
+// ParseTraceparent parses a traceparent header, eg. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+// Values with a version above 00 are parsed as far as version 00 goes, as the specification requires.
+func ParseTraceparent(value string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || !isLowerHex(parts[0], 2) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	if !isLowerHex(parts[1], 32) || !isLowerHex(parts[2], 16) || !isLowerHex(parts[3], 2) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	flags, _ := strconv.ParseUint(parts[3], 16, 8)
+	return TraceContext{TraceID: parts[1], SpanID: parts[2], Sampled: flags&1 == 1}, nil
+}
+
+func isLowerHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Traceparent formats the trace context as a version 00 traceparent header.
+func (traceContext TraceContext) Traceparent() string {
+	flags := "00"
+	if traceContext.Sampled {
+		flags = "01"
+	}
+	return "00-" + traceContext.TraceID + "-" + traceContext.SpanID + "-" + flags
+}
+
+// ExtractTraceContext returns the trace context of the traceparent and tracestate headers, and false if there
+// is no valid traceparent.
+func ExtractTraceContext(header http.Header) (TraceContext, bool) {
+	traceContext, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return TraceContext{}, false
+	}
+	traceContext.TraceState = strings.Join(header.Values(TracestateHeader), ",")
+	return traceContext, true
+}
+
+// InjectTraceContext sets the traceparent and tracestate headers for the span carried by ctx. It does nothing
+// when ctx carries no span.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	traceContext := span.TraceContext()
+	header.Set(TraceparentHeader, traceContext.Traceparent())
+	if traceContext.TraceState != "" {
+		header.Set(TracestateHeader, traceContext.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// ContinueTransaction starts a transaction in the trace of traceContext, received from the calling service,
+// and returns a context carrying it. The span of the caller is the parent of the transaction.
+func (trakerrClient *TrakerrClient) ContinueTransaction(ctx context.Context, traceContext TraceContext, name string) (context.Context, *Span) {
+	ctx, span := trakerrClient.StartTransaction(ctx, name)
+	span.traceID = traceContext.TraceID
+	span.parentSpanID = traceContext.SpanID
+	span.sampled = traceContext.Sampled
+	span.traceState = traceContext.TraceState
+	return ctx, span
+}
+
+// TraceContext returns the trace context to propagate to the services called within the span.
+func (span *Span) TraceContext() TraceContext {
+	return TraceContext{TraceID: span.traceID, SpanID: span.spanID, Sampled: span.sampled, TraceState: span.traceState}
+}
+
+// SetTraceSlots sets the CustomProperties.StringData slots the trace and span IDs of the span an event is sent in
+// are attached to, DefaultTraceIDSlot and DefaultSpanIDSlot unless changed. Slots already set on the event are
+// left alone. 0 turns a slot off.
+func (trakerrClient *TrakerrClient) SetTraceSlots(traceIDSlot int, spanIDSlot int) {
+	if traceIDSlot < 0 || traceIDSlot > 10 || spanIDSlot < 0 || spanIDSlot > 10 {
+		panic("trakerr: trace slots are out of range, use 1 to 10")
+	}
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.traceIDSlot = traceIDSlot
+	trakerrClient.spanIDSlot = spanIDSlot
+}
@@ -0,0 +1,108 @@
+package trakerr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	traceContext, err := ParseTraceparent(testTraceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traceContext.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || traceContext.SpanID != "00f067aa0ba902b7" || !traceContext.Sampled {
+		t.Errorf("unexpected trace context %+v", traceContext)
+	}
+	if traceContext.Traceparent() != testTraceparent {
+		t.Errorf("Traceparent() = %q", traceContext.Traceparent())
+	}
+	if future, err := ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); err != nil || future.Sampled {
+		t.Errorf("a future version was not parsed: %+v %v", future, err)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(invalid); err != ErrInvalidTraceparent {
+			t.Errorf("ParseTraceparent(%q) = %v, want ErrInvalidTraceparent", invalid, err)
+		}
+	}
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	client, recorder := newTestClient(t)
+	var outgoing http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outgoing = r.Header.Clone()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer downstream.Close()
+
+	httpClient := &http.Client{Transport: client.RoundTripper(nil)}
+	handler := client.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, _ := http.NewRequestWithContext(r.Context(), "GET", downstream.URL+"/inventory", nil)
+		response, err := httpClient.Do(request)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		response.Body.Close()
+		panic("out of stock")
+	}))
+
+	request := httptest.NewRequest("GET", "/checkout", nil)
+	request.Header.Set(TraceparentHeader, testTraceparent)
+	request.Header.Set(TracestateHeader, "vendor=abc")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	injected, err := ParseTraceparent(outgoing.Get(TraceparentHeader))
+	if err != nil {
+		t.Fatalf("no traceparent sent downstream: %v", err)
+	}
+	if injected.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || injected.SpanID == "00f067aa0ba902b7" || outgoing.Get(TracestateHeader) != "vendor=abc" {
+		t.Errorf("unexpected trace context sent downstream %q %q", outgoing.Get(TraceparentHeader), outgoing.Get(TracestateHeader))
+	}
+
+	events := recorder.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected the 502 and the panic, got %d events", len(events))
+	}
+	downstreamEvent, panicEvent := events[0], events[1]
+	if !hasTag(downstreamEvent, "span.id:"+injected.SpanID) {
+		t.Errorf("the downstream error is not linked to its span: %v", downstreamEvent.ContextTags)
+	}
+	for _, event := range events {
+		if event.ContextCrossAppCorrelationId != injected.TraceID || event.CustomProperties.StringData.CustomData9 != injected.TraceID {
+			t.Errorf("trace ID not attached to %q: %q %+v", event.EventMessage, event.ContextCrossAppCorrelationId, event.CustomProperties.StringData)
+		}
+	}
+	if panicEvent.CustomProperties.StringData.CustomData10 == "" || panicEvent.CustomProperties.StringData.CustomData10 == injected.SpanID {
+		t.Errorf("unexpected span ID slot %q", panicEvent.CustomProperties.StringData.CustomData10)
+	}
+}
+
+func TestSetTraceSlots(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetTraceSlots(1, 0)
+	ctx, span := client.StartTransaction(context.Background(), "job")
+	template := client.NewAppEvent("info", "", "Job", "done")
+	template.CustomProperties.StringData.CustomData10 = "kept"
+	if _, err := client.SendEventContext(ctx, template); err != nil {
+		t.Fatal(err)
+	}
+	data := recorder.Events()[0].CustomProperties.StringData
+	if data.CustomData1 != span.TraceID() || data.CustomData9 != "" || data.CustomData10 != "kept" {
+		t.Errorf("unexpected slots %+v", data)
+	}
+}
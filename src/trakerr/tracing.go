@@ -0,0 +1,315 @@
+package trakerr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Span statuses.
+const (
+	SpanStatusOK    = "ok"
+	SpanStatusError = "error"
+)
+
+// Tag keys linking an event to the span it was sent in.
+const (
+	TraceIDTagKey     = "trace.id"
+	SpanIDTagKey      = "span.id"
+	TransactionTagKey = "transaction"
+)
+
+// TransactionEventType is the EventType of the performance events sent for slow transactions.
+const TransactionEventType = "Transaction"
+
+// BreadcrumbCategorySpan is the breadcrumb category of the spans attached to a transaction event.
+const BreadcrumbCategorySpan = "span"
+
+// Span is a timed part of the work done for a request. The root span of a trace is its transaction; the spans
+// started in it are its children. A Span is safe for concurrent use.
+type Span struct {
+	client       *TrakerrClient
+	transaction  *Span
+	scope        *Scope
+	traceID      string
+	spanID       string
+	parentSpanID string
+	sampled      bool
+	traceState   string
+	start        time.Time
+
+	mu         sync.Mutex
+	name       string
+	end        time.Time
+	status     string
+	attributes map[string]string
+	spans      []*Span
+}
+
+type spanContextKey struct{}
+
+// StartTransaction starts a new trace and returns a context carrying its root span, the transaction.
+func (trakerrClient *TrakerrClient) StartTransaction(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{client: trakerrClient, scope: ScopeFromContext(ctx), name: name, traceID: newTraceID(), spanID: newSpanID(), sampled: true, start: time.Now()}
+	span.transaction = span
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// StartSpan starts a child of the span carried by ctx and returns a context carrying it. Without a span in ctx
+// it starts a transaction.
+func (trakerrClient *TrakerrClient) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return trakerrClient.StartTransaction(ctx, name)
+	}
+	span := &Span{
+		client:       trakerrClient,
+		transaction:  parent.transaction,
+		scope:        parent.scope,
+		name:         name,
+		traceID:      parent.traceID,
+		spanID:       newSpanID(),
+		parentSpanID: parent.spanID,
+		sampled:      parent.sampled,
+		traceState:   parent.traceState,
+		start:        time.Now(),
+	}
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// SpanFromContext returns the span carried by ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SetTransactionThreshold sends a performance event for every transaction that takes at least threshold, with
+// its spans as breadcrumbs. Zero, the default, sends none.
+func (trakerrClient *TrakerrClient) SetTransactionThreshold(threshold time.Duration) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.transactionThreshold = threshold
+}
+
+// Name returns the name of the span.
+func (span *Span) Name() string {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	return span.name
+}
+
+// SetName renames the span, eg. once the route of a request is known.
+func (span *Span) SetName(name string) {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	span.name = name
+}
+
+// TraceID returns the 32 hex digit ID of the trace the span belongs to.
+func (span *Span) TraceID() string {
+	return span.traceID
+}
+
+// SpanID returns the 16 hex digit ID of the span.
+func (span *Span) SpanID() string {
+	return span.spanID
+}
+
+// ParentSpanID returns the ID of the span this one was started in, "" for a transaction.
+func (span *Span) ParentSpanID() string {
+	return span.parentSpanID
+}
+
+// Transaction returns the root span of the trace.
+func (span *Span) Transaction() *Span {
+	return span.transaction
+}
+
+// IsTransaction reports whether the span is the root span of its trace.
+func (span *Span) IsTransaction() bool {
+	return span.transaction == span
+}
+
+// SetAttribute sets an attribute of the span.
+func (span *Span) SetAttribute(key string, value string) {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	if span.attributes == nil {
+		span.attributes = make(map[string]string)
+	}
+	span.attributes[key] = value
+}
+
+// SetStatus sets the status of the span, eg. SpanStatusError. Spans finished without one are SpanStatusOK.
+func (span *Span) SetStatus(status string) {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	span.status = status
+}
+
+// Status returns the status of the span, "" while it is running without one.
+func (span *Span) Status() string {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	return span.status
+}
+
+// Duration returns the duration of a finished span, or the time elapsed so far.
+func (span *Span) Duration() time.Duration {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	if span.end.IsZero() {
+		return time.Since(span.start)
+	}
+	return span.end.Sub(span.start)
+}
+
+// Finish ends the span. Finishing a transaction sends the performance event when it took at least the
+// transaction threshold; spans finished after their transaction are left out of it. Calling Finish again does nothing.
+func (span *Span) Finish() {
+	span.mu.Lock()
+	if !span.end.IsZero() {
+		span.mu.Unlock()
+		return
+	}
+	span.end = time.Now()
+	if span.status == "" {
+		span.status = SpanStatusOK
+	}
+	span.mu.Unlock()
+
+	if !span.IsTransaction() {
+		span.transaction.mu.Lock()
+		if span.transaction.end.IsZero() {
+			span.transaction.spans = append(span.transaction.spans, span)
+		}
+		span.transaction.mu.Unlock()
+		return
+	}
+
+	span.client.mu.RLock()
+	threshold := span.client.transactionThreshold
+	span.client.mu.RUnlock()
+	if threshold > 0 && span.Duration() >= threshold {
+		ctx := context.WithValue(context.Background(), spanContextKey{}, span)
+		if span.scope != nil {
+			ctx = context.WithValue(ctx, scopeContextKey{}, span.scope)
+		}
+		span.client.sendContext(ctx, span.event(), Hint{})
+	}
+}
+
+// event returns the performance event of a finished transaction.
+func (span *Span) event() *AppEvent {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	duration := span.end.Sub(span.start)
+	message := fmt.Sprintf("%s took %s", span.name, duration.Round(time.Millisecond))
+	appEvent := span.client.NewAppEvent("info", "", TransactionEventType, message)
+	appEvent.ContextOperationTimeMillis = int64(duration / time.Millisecond)
+
+	// the trace and span IDs are added when the event is sent with the transaction
+	tags := map[string]string{"transaction.status": span.status}
+	for key, value := range span.attributes {
+		tags[key] = value
+	}
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, tags)
+
+	appEvent.Breadcrumbs = make([]Breadcrumb, 0, len(span.spans))
+	for _, child := range span.spans {
+		appEvent.Breadcrumbs = append(appEvent.Breadcrumbs, child.breadcrumb())
+	}
+	return appEvent
+}
+
+// breadcrumb describes a finished span for the event of its transaction.
+func (span *Span) breadcrumb() Breadcrumb {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+	level := "info"
+	if span.status == SpanStatusError {
+		level = "error"
+	}
+	data := map[string]string{
+		"span_id":        span.spanID,
+		"parent_span_id": span.parentSpanID,
+		"status":         span.status,
+		"duration_ms":    strconv.FormatInt(int64(span.end.Sub(span.start)/time.Millisecond), 10),
+	}
+	for key, value := range span.attributes {
+		data[key] = value
+	}
+	return Breadcrumb{
+		Timestamp: span.start.UnixNano() / int64(time.Millisecond),
+		Category:  BreadcrumbCategorySpan,
+		Level:     level,
+		Message:   span.name,
+		Data:      data,
+	}
+}
+
+// linkSpan tags appEvent with the span carried by ctx and attaches the trace ID as the correlation ID and the
+// trace and span IDs to their custom data slots. An error or fatal event marks the span as failed.
+func (trakerrClient *TrakerrClient) linkSpan(ctx context.Context, appEvent *AppEvent) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, map[string]string{
+		TraceIDTagKey:     span.traceID,
+		SpanIDTagKey:      span.spanID,
+		TransactionTagKey: span.transaction.Name(),
+	})
+	if appEvent.ContextCrossAppCorrelationId == "" {
+		appEvent.ContextCrossAppCorrelationId = span.traceID
+	}
+	trakerrClient.mu.RLock()
+	traceIDSlot, spanIDSlot := trakerrClient.traceIDSlot, trakerrClient.spanIDSlot
+	trakerrClient.mu.RUnlock()
+	ids := CustomData{}
+	if traceIDSlot != 0 {
+		ids.SetString(traceIDSlot, span.traceID)
+	}
+	if spanIDSlot != 0 {
+		ids.SetString(spanIDSlot, span.spanID)
+	}
+	mergeCustomData(&appEvent.CustomProperties, ids)
+	if appEvent.LogLevel == "error" || appEvent.LogLevel == "fatal" {
+		span.mu.Lock()
+		if span.end.IsZero() {
+			span.status = SpanStatusError
+		}
+		span.mu.Unlock()
+	}
+}
+
+func newTraceID() string {
+	return randomHex(16)
+}
+
+func newSpanID() string {
+	return randomHex(8)
+}
+
+// randomHex returns n random bytes in hex. IDs that are all zeros are invalid in W3C trace context, so they are redrawn.
+func randomHex(n int) string {
+	id := make([]byte, n)
+	for {
+		if _, err := rand.Read(id); err != nil {
+			panic("trakerr: cannot generate a trace ID: " + err.Error())
+		}
+		for _, b := range id {
+			if b != 0 {
+				return hex.EncodeToString(id)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package trakerr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSpansNestInTransaction(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx, transaction := client.StartTransaction(context.Background(), "GET /checkout")
+	childCtx, child := client.StartSpan(ctx, "charge")
+	_, grandchild := client.StartSpan(childCtx, "http")
+
+	if len(transaction.TraceID()) != 32 || len(transaction.SpanID()) != 16 || !transaction.IsTransaction() {
+		t.Errorf("unexpected transaction IDs %q %q", transaction.TraceID(), transaction.SpanID())
+	}
+	if child.TraceID() != transaction.TraceID() || child.ParentSpanID() != transaction.SpanID() || child.Transaction() != transaction {
+		t.Error("the child span is not linked to the transaction")
+	}
+	if grandchild.ParentSpanID() != child.SpanID() || grandchild.SpanID() == child.SpanID() {
+		t.Error("the grandchild span is not linked to its parent")
+	}
+	if SpanFromContext(childCtx) != child {
+		t.Error("the context doesn't carry the span")
+	}
+	if _, root := client.StartSpan(context.Background(), "job"); !root.IsTransaction() {
+		t.Error("a span started without a parent is not a transaction")
+	}
+}
+
+func TestErrorsAreLinkedToSpans(t *testing.T) {
+	client, recorder := newTestClient(t)
+	ctx, transaction := client.StartTransaction(context.Background(), "GET /checkout")
+	spanCtx, span := client.StartSpan(ctx, "charge")
+
+	if _, err := client.SendErrorContext(spanCtx, "error", "", errors.New("card declined")); err != nil {
+		t.Fatal(err)
+	}
+	span.Finish()
+	transaction.Finish()
+
+	event := recorder.Events()[0]
+	for _, tag := range []string{"trace.id:" + span.TraceID(), "span.id:" + span.SpanID(), "transaction:GET /checkout"} {
+		if !hasTag(event, tag) {
+			t.Errorf("tag %s missing from %v", tag, event.ContextTags)
+		}
+	}
+	if span.Status() != SpanStatusError || transaction.Status() != SpanStatusOK {
+		t.Errorf("unexpected statuses %q %q", span.Status(), transaction.Status())
+	}
+}
+
+func TestSlowTransactionsAreSent(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetTransactionThreshold(5 * time.Millisecond)
+
+	_, fast := client.StartTransaction(context.Background(), "fast")
+	fast.Finish()
+
+	ctx, slow := client.StartTransaction(context.Background(), "slow")
+	slow.SetAttribute("route", "/reports")
+	_, query := client.StartSpan(ctx, "query")
+	query.SetAttribute("table", "orders")
+	time.Sleep(6 * time.Millisecond)
+	query.Finish()
+	slow.Finish()
+	slow.Finish()
+
+	events := recorder.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	event := events[0]
+	if event.EventType != TransactionEventType || event.ContextOperationTimeMillis < 6 {
+		t.Errorf("unexpected event %q %dms", event.EventType, event.ContextOperationTimeMillis)
+	}
+	for _, tag := range []string{"transaction:slow", "transaction.status:ok", "route:/reports", "trace.id:" + slow.TraceID()} {
+		if !hasTag(event, tag) {
+			t.Errorf("tag %s missing from %v", tag, event.ContextTags)
+		}
+	}
+	if len(event.Breadcrumbs) != 1 || event.Breadcrumbs[0].Message != "query" || event.Breadcrumbs[0].Data["table"] != "orders" || event.Breadcrumbs[0].Data["parent_span_id"] != slow.SpanID() {
+		t.Errorf("unexpected span breadcrumbs %+v", event.Breadcrumbs)
+	}
+}
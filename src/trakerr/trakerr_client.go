@@ -3,12 +3,13 @@
 package trakerr
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -36,7 +37,11 @@ func getTextFromLine(text string, prefix string, suffix string) string {
 //TrakerrClient is the class that sends events to Trakerr.
 //In a normal use case, Trakerr populates the field below with default values, but can be set after constuction to a custom value.
 //The discription for the fields are below, and the struct associated methods below that.
+//A TrakerrClient is safe for concurrent use by multiple goroutines. The send and WithAppEvent methods copy
+//the AppEvent they are given before filling it in, so one AppEvent can be shared as a template. FillDefaults
+//and AddStackTraceToAppEvent change the event they are given; call them on a copy (AppEvent.Copy) instead.
 type TrakerrClient struct {
+	mu                         sync.RWMutex
 	apiKey                     string
 	contextAppVersion          string
 	contextDeploymentStage     string
@@ -52,6 +57,20 @@ type TrakerrClient struct {
 	contextDataCenterRegion    string
 	eventsAPI                  EventsApi
 	eventTraceBuilder          EventTraceBuilder
+	contextTags                map[string]string
+	breadcrumbs                *BreadcrumbBuffer
+	sampling                   samplingRules
+	dedup                      *deduplicator
+	limiter                    *tokenBucket
+	breaker                    *circuitBreaker
+	scrubber                   *Scrubber
+	processors                 []EventProcessor
+	timerSlots                 map[string]int
+	transactionThreshold       time.Duration
+	traceIDSlot                int
+	spanIDSlot                 int
+	session                    *Session
+	sessions                   *sessionAggregator
 }
 
 //apiKey is your API key string.
@@ -67,6 +86,8 @@ type TrakerrClient struct {
 //contextAppBrowserVersion is an optional string browser version the application is running on.
 //contextDatacenter is the optional datacenter the code may be running on.
 //contextDatacenterRegion is the optional datacenter region the code may be running on.
+//contextTags are the key/value tags added to every event, such as the detected container and Kubernetes pod
+//and the VCS revision the binary was built from.
 
 // NewTrakerrClient creates a new TrakerrClient and return it with the data.
 // Most parameters are optional i.e. empty (pass "" to use defaults) with the exception of apiKey which is required.
@@ -78,6 +99,11 @@ func NewTrakerrClient(
 	if contextDeploymentStage == "" {
 		contextDeploymentStage = "development"
 	}
+	//Without an explicit version, use the one the Go toolchain embedded in the binary.
+	buildInfo := CurrentBuildInfo()
+	if contextAppVersion == "" {
+		contextAppVersion = buildInfo.AppVersion()
+	}
 	if contextAppVersion == "" {
 		contextAppVersion = "1.0"
 	}
@@ -90,73 +116,19 @@ func NewTrakerrClient(
 	contextEnvVersion := runtime.Version()
 	contextEnvHostname, _ := os.Hostname()
 
-	contextAppOS := runtime.GOOS
-	var contextAppOSVersion string
-	switch contextAppOS {
-	case "windows": // Keep an eye out for OS's using carrage returns
-		//cmd1 := exec.Command("systeminfo")
-		cmd := exec.Command("cmd", "/C", "systeminfo") //exec.Command("findstr", "/C:\"OS Name\"")
-		var out bytes.Buffer
-		cmd.Stdout = &out
-
-		err1 := cmd.Run()
-		if err1 != nil {
-			contextAppOS = runtime.GOOS
-			contextAppOSVersion = "N/A (arch:" + runtime.GOARCH + ")"
-		} else {
-			var output = out.String()
-			contextAppOS = getTextFromLine(output, "OS Name:", "\n")
-			version := getTextFromLine(output, "OS Version:", "\n")
-			versionstringsplit := strings.Split(version, " ")
-
-			if len(versionstringsplit) >= 1 {
-				contextAppOSVersion = versionstringsplit[0]
-			} else {
-				contextAppOSVersion = version
-			}
-		}
-
-	case "darwin": // ...
-		cmd := exec.Command("bash", "-c", "system_profiler SPSoftwareDataType") //exec.Command("findstr", "/C:\"OS Name\"")
-		var out bytes.Buffer
-		cmd.Stdout = &out
-
-		err1 := cmd.Run()
-		if err1 != nil {
-			contextAppOS = runtime.GOOS
-			contextAppOSVersion = "N/A (arch:" + runtime.GOARCH + ")"
-		} else {
-			var output = out.String()
-
-			contextAppOS = getTextFromLine(output, "System Version:", "(")
-			contextAppOSVersion = getTextFromLine(output, "Kernel Version:", "\n")
-		}
-
-	default:
-		cmd := exec.Command("bash", "-c", "uname -s") //Uname -r and -s
-		var out bytes.Buffer
-		cmd.Stdout = &out
-
-		err1 := cmd.Run()
-		var output string
-		if err1 != nil {
-			contextAppOS = runtime.GOOS
-		} else {
-			output = out.String()
-
-			contextAppOS = strings.Trim(output, " \r\n")
-		}
-
-		cmd = exec.Command("bash", "-c", "uname -r") //Uname -r and -s
-		err1 = cmd.Run()
-		if err1 != nil {
-			contextAppOSVersion = "N/A (arch:" + runtime.GOARCH + ")"
-		} else {
-			output = out.String()
+	//In Kubernetes the hostname is the pod name, which is sent as a tag; the node is the actual host.
+	containerInfo := CurrentContainerInfo()
+	if containerInfo.NodeName != "" {
+		contextEnvHostname = containerInfo.NodeName
+	}
 
-			contextAppOSVersion = strings.Trim(output, " \r\n")
-		}
+	osInfo := CurrentOSInfo()
+	contextAppOS := osInfo.Name
+	contextAppOSVersion := osInfo.Version
 
+	contextTags := containerInfo.Tags()
+	for key, value := range buildInfo.Tags() {
+		contextTags[key] = value
 	}
 
 	var eventsAPI EventsApi
@@ -175,11 +147,65 @@ func NewTrakerrClient(
 		contextDataCenter:       "",
 		contextDataCenterRegion: "",
 		eventsAPI:               eventsAPI,
-		eventTraceBuilder:       EventTraceBuilder{}}
+		eventTraceBuilder:       EventTraceBuilder{},
+		contextTags:             contextTags,
+		breadcrumbs:             NewBreadcrumbBuffer(DefaultMaxBreadcrumbs),
+		sampling:                newSamplingRules(),
+		traceIDSlot:             DefaultTraceIDSlot,
+		spanIDSlot:              DefaultSpanIDSlot}
 }
 
-//NewAppEvent returns an AppEvent pointer with the classification eventType and eventMessage filled.
-func (trakerrClient *TrakerrClient) NewAppEvent(loglevel string, classification string, eventType string, eventMessage string) *AppEvent {
+//SetContextTag sets a key/value tag that is added to the ContextTags of every event as "key:value".
+func (trakerrClient *TrakerrClient) SetContextTag(key string, value string) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.contextTags[key] = value
+}
+
+//RemoveContextTag removes a tag set with SetContextTag or by environment detection.
+func (trakerrClient *TrakerrClient) RemoveContextTag(key string) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	delete(trakerrClient.contextTags, key)
+}
+
+//SetContainerInfo replaces the automatically detected container and Kubernetes tags, for example with
+//the result of an EnvironmentDetector pointed at a different root.
+func (trakerrClient *TrakerrClient) SetContainerInfo(info ContainerInfo) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	for _, key := range containerTagKeys {
+		delete(trakerrClient.contextTags, key)
+	}
+	for key, value := range info.Tags() {
+		trakerrClient.contextTags[key] = value
+	}
+	if info.NodeName != "" {
+		trakerrClient.contextEnvHostname = info.NodeName
+	} else {
+		trakerrClient.contextEnvHostname, _ = os.Hostname()
+	}
+}
+
+//SetBaseURL sends the events to the Trakerr API at baseURL instead of https://www.trakerr.io/api/v1,
+//eg. a proxy or a trakerrtest.Server.
+func (trakerrClient *TrakerrClient) SetBaseURL(baseURL string) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.eventsAPI.Configuration.BasePath = strings.TrimSuffix(baseURL, "/")
+}
+
+//SetDataCenter sets the data center and region sent with every event. DetectDataCenter fills them from the
+//instance metadata of the cloud the application runs on.
+func (trakerrClient *TrakerrClient) SetDataCenter(dataCenter string, region string) {
+	trakerrClient.mu.Lock()
+	defer trakerrClient.mu.Unlock()
+	trakerrClient.contextDataCenter = dataCenter
+	trakerrClient.contextDataCenterRegion = region
+}
+
+//normalizeLogLevel lowercases loglevel and replaces anything that isn't a known level with "error".
+func normalizeLogLevel(loglevel string) string {
 	loglevel = strings.ToLower(loglevel)
 
 	visitedURL := map[string]bool{
@@ -192,6 +218,12 @@ func (trakerrClient *TrakerrClient) NewAppEvent(loglevel string, classification
 	if !visitedURL[loglevel] {
 		loglevel = "error"
 	}
+	return loglevel
+}
+
+//NewAppEvent returns an AppEvent pointer with the classification eventType and eventMessage filled.
+func (trakerrClient *TrakerrClient) NewAppEvent(loglevel string, classification string, eventType string, eventMessage string) *AppEvent {
+	loglevel = normalizeLogLevel(loglevel)
 	if classification == "" {
 		classification = "issue"
 	}
@@ -201,7 +233,7 @@ func (trakerrClient *TrakerrClient) NewAppEvent(loglevel string, classification
 	if eventMessage == "" {
 		eventMessage = "unknown"
 	}
-	return trakerrClient.FillDefaults(&AppEvent{Classification: classification, EventType: eventType, EventMessage: eventMessage})
+	return trakerrClient.FillDefaults(&AppEvent{LogLevel: loglevel, Classification: classification, EventType: eventType, EventMessage: eventMessage})
 }
 
 //NewEmptyEvent returns a Appevent pointer which is empty. If the AppEvent is passed into a defer later, classification, eventType, and eventMessage
@@ -211,8 +243,105 @@ func (trakerrClient *TrakerrClient) NewEmptyEvent() *AppEvent {
 }
 
 //SendEvent sends the event to trakerr.
+//The client wide breadcrumb trail is attached to the event if it doesn't already carry one.
 func (trakerrClient *TrakerrClient) SendEvent(appEvent *AppEvent) (*APIResponse, error) {
-	return trakerrClient.eventsAPI.EventsPost(*trakerrClient.FillDefaults(appEvent))
+	return trakerrClient.SendEventContext(context.Background(), appEvent)
+}
+
+//SendEventContext sends the event to trakerr after merging in the Scope carried by ctx, if any.
+//The event is copied before it is filled in, so the breadcrumb trail is captured fresh each time the same
+//AppEvent is sent and the caller's event is left untouched.
+//It returns an error wrapping ErrEventDropped, such as ErrSampled, when the event is deliberately not sent.
+func (trakerrClient *TrakerrClient) SendEventContext(ctx context.Context, appEvent *AppEvent) (*APIResponse, error) {
+	return trakerrClient.sendContext(ctx, appEvent, Hint{})
+}
+
+//sendContext is the implementation behind SendEventContext; hint is passed to the event processors.
+func (trakerrClient *TrakerrClient) sendContext(ctx context.Context, appEvent *AppEvent, hint Hint) (*APIResponse, error) {
+	event := appEvent.Copy()
+	trakerrClient.applyContext(ctx, event)
+	trakerrClient.fillContextTags(event)
+	event = trakerrClient.FillDefaults(event)
+	trakerrClient.applyTimers(ctx, event)
+	trakerrClient.linkSpan(ctx, event)
+	trakerrClient.trackSession(ctx, event, hint)
+	event = trakerrClient.processEvent(ctx, event, hint)
+	if event == nil {
+		return nil, ErrDroppedByProcessor
+	}
+	return trakerrClient.send(event)
+}
+
+//send is the pipeline every event goes through once it is filled in and processed: scrubbing, duplicate suppression,
+//sampling, then the events API.
+func (trakerrClient *TrakerrClient) send(appEvent *AppEvent) (*APIResponse, error) {
+	trakerrClient.mu.RLock()
+	scrubber := trakerrClient.scrubber
+	dedup := trakerrClient.dedup
+	trakerrClient.mu.RUnlock()
+	if scrubber != nil {
+		scrubber.Scrub(appEvent)
+	}
+	if dedup != nil && dedup.suppress(appEvent, time.Now()) {
+		return nil, ErrDuplicate
+	}
+	if !trakerrClient.sample(appEvent) {
+		return nil, ErrSampled
+	}
+	return trakerrClient.post(appEvent)
+}
+
+//post sends an event that went through the pipeline to the events API, unless the rate limiter or the
+//circuit breaker hold it back.
+func (trakerrClient *TrakerrClient) post(appEvent *AppEvent) (*APIResponse, error) {
+	trakerrClient.mu.RLock()
+	limiter := trakerrClient.limiter
+	breaker := trakerrClient.breaker
+	eventsAPI := trakerrClient.eventsAPI
+	trakerrClient.mu.RUnlock()
+
+	//The breaker goes first, so the events it drops don't use up the rate budget.
+	if breaker != nil && !breaker.allow(time.Now()) {
+		return nil, ErrCircuitOpen
+	}
+	if limiter != nil && !limiter.allow(time.Now()) {
+		if breaker != nil {
+			breaker.release()
+		}
+		return nil, ErrRateLimited
+	}
+	response, err := eventsAPI.EventsPost(*appEvent)
+	if limiter != nil {
+		if until := retryAfter(response, time.Now()); !until.IsZero() {
+			limiter.backOff(until)
+		}
+	}
+	if breaker != nil {
+		breaker.record(!sendFailed(response, err), time.Now())
+	}
+	return response, err
+}
+
+//handleRecoveredSend reports the result of sending a recovered panic. Failed responses are printed and
+//API errors are raised as a panic; events dropped on purpose (ErrEventDropped) are not errors.
+func handleRecoveredSend(response *APIResponse, apierr error) {
+	if errors.Is(apierr, ErrEventDropped) {
+		return
+	}
+	if response != nil && response.Response != nil && response.StatusCode > 399 {
+		fmt.Println(response.Status)
+	}
+	if apierr != nil {
+		panic(apierr)
+	}
+}
+
+//fillContextTags adds the client wide tags whose key the event doesn't have yet. It runs after the Scope
+//is applied, so per-request tags take precedence over the client defaults.
+func (trakerrClient *TrakerrClient) fillContextTags(appEvent *AppEvent) {
+	trakerrClient.mu.RLock()
+	defer trakerrClient.mu.RUnlock()
+	appEvent.ContextTags = mergeTags(appEvent.ContextTags, trakerrClient.contextTags)
 }
 
 //SendError outward facing method that creates an event and takes a classification and an error.
@@ -220,11 +349,22 @@ func (trakerrClient *TrakerrClient) SendError(loglevel string, classification st
 	trakerrClient.SendErrorWithSkip(err, loglevel, classification, 4)
 }
 
+//SendErrorContext creates an event from the error, merges in the Scope carried by ctx and sends it.
+func (trakerrClient *TrakerrClient) SendErrorContext(ctx context.Context, loglevel string, classification string, err interface{}) (*APIResponse, error) {
+	return trakerrClient.sendErrorWithSkipContext(ctx, err, false, loglevel, classification, 4)
+}
+
 //SendErrorWithSkip internal method that handles creating an app event and gets the stacktrace before sending.
 func (trakerrClient *TrakerrClient) SendErrorWithSkip(err interface{}, loglevel string, classification string, skip int) (*APIResponse, error) {
-	appEvent := trakerrClient.CreateAppEventFromErrorWithSkip(err, loglevel, classification, skip+1)
+	return trakerrClient.sendErrorWithSkipContext(context.Background(), err, false, loglevel, classification, skip+1)
+}
 
-	return trakerrClient.eventsAPI.EventsPost(*appEvent)
+//sendErrorWithSkipContext is the context aware implementation behind SendErrorWithSkip.
+//panicked is true when err was recovered from a panic.
+func (trakerrClient *TrakerrClient) sendErrorWithSkipContext(ctx context.Context, err interface{}, panicked bool, loglevel string, classification string, skip int) (*APIResponse, error) {
+	appEvent := trakerrClient.createAppEventFromErrorWithSkipContext(ctx, err, loglevel, classification, skip+1)
+
+	return trakerrClient.sendContext(ctx, appEvent, newHint(err, panicked))
 }
 
 //CreateAppEventFromError internal method that provides some default values for CreateAppEventFromErrorWithSkip.
@@ -233,17 +373,37 @@ func (trakerrClient *TrakerrClient) CreateAppEventFromError(loglevel string, cla
 
 }
 
+//CreateAppEventFromErrorContext creates an app event from the error like CreateAppEventFromError
+//and merges in the Scope carried by ctx.
+func (trakerrClient *TrakerrClient) CreateAppEventFromErrorContext(ctx context.Context, loglevel string, classification string, err interface{}) *AppEvent {
+	return trakerrClient.createAppEventFromErrorWithSkipContext(ctx, err, loglevel, classification, 4)
+}
+
 //CreateAppEventFromErrorWithSkip internal method which calls eventTraceBuilder to parse the stacktrace and creates an app event with it.
 //Pass "" to use the default value classification
 func (trakerrClient *TrakerrClient) CreateAppEventFromErrorWithSkip(err interface{}, loglevel string, classification string, skip int) *AppEvent {
+	return trakerrClient.createAppEventFromErrorWithSkipContext(context.Background(), err, loglevel, classification, skip+1)
+}
+
+//createAppEventFromErrorWithSkipContext is the context aware implementation behind CreateAppEventFromErrorWithSkip.
+func (trakerrClient *TrakerrClient) createAppEventFromErrorWithSkipContext(ctx context.Context, err interface{}, loglevel string, classification string, skip int) *AppEvent {
 	stacktrace := trakerrClient.eventTraceBuilder.GetEventTraces(err, 50, skip+1)
 	event := trakerrClient.NewAppEvent(loglevel, classification, fmt.Sprintf("%T", err), fmt.Sprint(err))
 
 	result := trakerrClient.FillDefaults(event)
 	result.EventStacktrace = stacktrace
+	trakerrClient.applyContext(ctx, result)
 	return result
 }
 
+//applyContext merges the Scope carried by ctx into appEvent and attaches the breadcrumb trail for ctx.
+func (trakerrClient *TrakerrClient) applyContext(ctx context.Context, appEvent *AppEvent) {
+	if scope := ScopeFromContext(ctx); scope != nil {
+		scope.ApplyToEvent(appEvent)
+	}
+	trakerrClient.AttachBreadcrumbs(ctx, appEvent)
+}
+
 //AddStackTraceToAppEvent internal method to add a stack trace to an already exisiting AppEvent.
 //Useful for creating your app event first to populate custom data.
 //appEvent's EventType and EventMessaage are filled with the details from the error.
@@ -264,28 +424,19 @@ func (trakerrClient *TrakerrClient) AddStackTraceToAppEvent(appEvent *AppEvent,
 //Use in a Defer statement. The loglevel is the the string classifiction of the error (ie: "Error", "Info", ect).
 func (trakerrClient *TrakerrClient) Recover(loglevel string, classification string) {
 	if err := recover(); err != nil {
-		response, apierr := trakerrClient.SendErrorWithSkip(err, loglevel, classification, 4)
-		if response.StatusCode > 399 {
-			fmt.Println(response.Status)
-		}
-		if apierr != nil {
-			panic(apierr)
-		}
+		handleRecoveredSend(trakerrClient.sendErrorWithSkipContext(context.Background(), err, true, loglevel, classification, 4))
 	}
 }
 
 //RecoverWithAppEvent recovers from a panic and sends the error to Trakerr from a defer statement.
 //This function takes in an AppEvent so could popultate the AppEvent with custom data and then attach the err from the defer.
+//The AppEvent is treated as a template: it is copied before the error is attached, so it can be shared between goroutines.
 func (trakerrClient *TrakerrClient) RecoverWithAppEvent(appEvent *AppEvent) {
 	if err := recover(); err != nil {
-		trakerrClient.AddStackTraceToAppEvent(appEvent, err, 4)
-		response, apierr := trakerrClient.SendEvent(appEvent)
-		if response.StatusCode > 399 {
-			fmt.Println(response.Status)
-		}
-		if apierr != nil {
-			panic(apierr)
-		}
+		event := appEvent.Copy()
+		event.EventTime = 0 //stamp the time of the panic, not of the template
+		trakerrClient.AddStackTraceToAppEvent(event, err, 4)
+		handleRecoveredSend(trakerrClient.sendContext(context.Background(), event, newHint(err, true)))
 
 	}
 }
@@ -295,13 +446,24 @@ func (trakerrClient *TrakerrClient) RecoverWithAppEvent(appEvent *AppEvent) {
 //Use in a Defer statement.
 func (trakerrClient *TrakerrClient) Notify(loglevel string, classification string) {
 	if err := recover(); err != nil {
-		response, apierr := trakerrClient.SendErrorWithSkip(err, loglevel, classification, 4)
-		if response.StatusCode > 399 {
-			fmt.Println(response.Status)
-		}
-		if apierr != nil {
-			panic(apierr)
-		}
+		handleRecoveredSend(trakerrClient.sendErrorWithSkipContext(context.Background(), err, true, loglevel, classification, 4))
+		panic(err)
+	}
+}
+
+//RecoverContext recovers from a panic and sends the error to Trakerr with the Scope carried by ctx merged in.
+//Use in a Defer statement.
+func (trakerrClient *TrakerrClient) RecoverContext(ctx context.Context, loglevel string, classification string) {
+	if err := recover(); err != nil {
+		handleRecoveredSend(trakerrClient.sendErrorWithSkipContext(ctx, err, true, loglevel, classification, 4))
+	}
+}
+
+//NotifyContext recovers from an error and then repanics after sending the error to Trakerr
+//with the Scope carried by ctx merged in. Use in a Defer statement.
+func (trakerrClient *TrakerrClient) NotifyContext(ctx context.Context, loglevel string, classification string) {
+	if err := recover(); err != nil {
+		handleRecoveredSend(trakerrClient.sendErrorWithSkipContext(ctx, err, true, loglevel, classification, 4))
 		panic(err)
 	}
 }
@@ -309,22 +471,23 @@ func (trakerrClient *TrakerrClient) Notify(loglevel string, classification strin
 //NotifyWithAppEvent recovers from an error and then repanics after sending the error to Trakerr,
 //so that the panic can be picked up by the program error handler. Use in a Defer statement.
 //This function takes in an AppEvent so could popultate the AppEvent with custom data and then attach the err from the defer.
+//The AppEvent is treated as a template: it is copied before the error is attached, so it can be shared between goroutines.
 func (trakerrClient *TrakerrClient) NotifyWithAppEvent(appEvent *AppEvent) {
 	if err := recover(); err != nil {
-		trakerrClient.AddStackTraceToAppEvent(appEvent, err, 4)
-		response, apierr := trakerrClient.SendEvent(appEvent)
-		if response.StatusCode > 399 {
-			fmt.Println(response.Status)
-		}
-		if apierr != nil {
-			panic(apierr)
-		}
+		event := appEvent.Copy()
+		event.EventTime = 0 //stamp the time of the panic, not of the template
+		trakerrClient.AddStackTraceToAppEvent(event, err, 4)
+		handleRecoveredSend(trakerrClient.sendContext(context.Background(), event, newHint(err, true)))
 		panic(err)
 	}
 }
 
 //FillDefaults Populates the appevent with the TrakerrClient defaults.
+//Client wide tags are added when the event is sent, after the per-request Scope.
 func (trakerrClient *TrakerrClient) FillDefaults(appEvent *AppEvent) *AppEvent {
+	trakerrClient.mu.RLock()
+	defer trakerrClient.mu.RUnlock()
+
 	if appEvent.ApiKey == "" {
 		appEvent.ApiKey = trakerrClient.apiKey
 	}
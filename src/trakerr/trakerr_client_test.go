@@ -0,0 +1,161 @@
+package trakerr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// eventRecorder is an httptest stand-in for the events endpoint that keeps every AppEvent it receives.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []AppEvent
+}
+
+func (recorder *eventRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var event AppEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	recorder.mu.Lock()
+	recorder.events = append(recorder.events, event)
+	recorder.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+func (recorder *eventRecorder) Events() []AppEvent {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return append([]AppEvent(nil), recorder.events...)
+}
+
+// newTestClient returns a TrakerrClient whose events go to an httptest server instead of Trakerr.
+func newTestClient(t *testing.T) (*TrakerrClient, *eventRecorder) {
+	recorder := &eventRecorder{}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+
+	client := NewTrakerrClient("test-api-key", "1.0", "test")
+	client.eventsAPI = *NewEventsApiWithBasePath(server.URL)
+	client.SetContainerInfo(ContainerInfo{}) //keep the tags independent of where the tests run
+	return client, recorder
+}
+
+func TestClientIsSafeForConcurrentUse(t *testing.T) {
+	client, recorder := newTestClient(t)
+	template := client.NewEmptyEvent()
+	template.CustomProperties.StringData.CustomData1 = "foo"
+	template.EventUser = "john@trakerr.io"
+	original := template.Copy()
+
+	const goroutines = 20
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(4)
+		go func(i int) {
+			defer wg.Done()
+			defer client.RecoverWithAppEvent(template)
+			panic(fmt.Sprintf("recover %d", i))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if err := recover(); err != fmt.Sprintf("notify %d", i) {
+					t.Errorf("NotifyWithAppEvent repanicked with %v", err)
+				}
+			}()
+			defer client.NotifyWithAppEvent(template)
+			panic(fmt.Sprintf("notify %d", i))
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			client.SetContextTag(fmt.Sprintf("tag%d", i%3), fmt.Sprint(i))
+			client.FillDefaults(template.Copy())
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			ctx, scope := WithScope(context.Background())
+			scope.SetSession(fmt.Sprint(i))
+			if _, err := client.SendEventContext(ctx, template); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if !reflect.DeepEqual(template, original) {
+		t.Errorf("template was modified:\n got  %+v\n want %+v", template, original)
+	}
+	events := recorder.Events()
+	if len(events) != 3*goroutines {
+		t.Fatalf("expected %d events, got %d", 3*goroutines, len(events))
+	}
+	panics := 0
+	for _, event := range events {
+		if event.EventStacktrace != nil {
+			panics++
+			if !strings.HasPrefix(event.EventMessage, "recover") && !strings.HasPrefix(event.EventMessage, "notify") {
+				t.Errorf("unexpected message %q", event.EventMessage)
+			}
+		}
+		if event.CustomProperties.StringData.CustomData1 != "foo" || event.EventUser != "john@trakerr.io" {
+			t.Errorf("template values missing from %+v", event)
+		}
+	}
+	if panics != 2*goroutines {
+		t.Errorf("expected %d events with a stack trace, got %d", 2*goroutines, panics)
+	}
+}
+
+func TestScopeTagsOverrideClientTags(t *testing.T) {
+	client, recorder := newTestClient(t)
+	client.SetContextTag("tenant", "client")
+	client.SetContextTag("region", "eu")
+	ctx, scope := WithScope(context.Background())
+	scope.SetTag("tenant", "scope")
+
+	event := client.CreateAppEventFromErrorContext(ctx, "error", "", fmt.Errorf("boom"))
+	if !reflect.DeepEqual(event.ContextTags, []string{"tenant:scope"}) {
+		t.Errorf("unexpected tags on created event %v", event.ContextTags)
+	}
+	if _, err := client.SendEventContext(ctx, client.NewAppEvent("info", "", "Type", "message")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SendEvent(event); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sent := range recorder.Events() {
+		if !reflect.DeepEqual(sent.ContextTags, []string{"tenant:scope", "region:eu"}) {
+			t.Errorf("expected the scope tag to win, got %v", sent.ContextTags)
+		}
+	}
+}
+
+func TestSetContainerInfoRestoresHostname(t *testing.T) {
+	client := NewTrakerrClient("key", "", "")
+	hostname, _ := os.Hostname()
+
+	client.SetContainerInfo(ContainerInfo{PodName: "web", NodeName: "node-a"})
+	if got := client.NewEmptyEvent().ContextEnvHostname; got != "node-a" {
+		t.Errorf("expected the node name, got %q", got)
+	}
+	client.SetContainerInfo(ContainerInfo{Runtime: "docker"})
+	event := client.NewEmptyEvent()
+	if event.ContextEnvHostname != hostname {
+		t.Errorf("expected hostname %q, got %q", hostname, event.ContextEnvHostname)
+	}
+	client.fillContextTags(event)
+	if !reflect.DeepEqual(event.ContextTags, []string{"container.runtime:docker"}) {
+		t.Errorf("stale container tags left behind: %v", event.ContextTags)
+	}
+}
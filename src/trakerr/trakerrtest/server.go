@@ -0,0 +1,214 @@
+// Package trakerrtest provides a fake Trakerr events API for tests, so code using a TrakerrClient can be tested
+// without sending events to https://www.trakerr.io/api/v1.
+//
+//	server := trakerrtest.NewServer()
+//	defer server.Close()
+//	client := trakerr.NewTrakerrClient("test-api-key", "1.0", "test")
+//	client.SetBaseURL(server.URL)
+package trakerrtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+)
+
+// Response is a scripted answer of the fake API.
+type Response struct {
+
+	// status code of the response, 200 when 0
+	Status int
+
+	// time to wait before answering
+	Delay time.Duration
+
+	// Retry-After header of the response, in whole seconds, eg. for a 429
+	RetryAfter time.Duration
+}
+
+// Handler implements the events API: POST /events validates the AppEvent against the model and records it.
+// GET /events returns the recorded events as a JSON array and DELETE /events forgets them, so tests that are not
+// written in Go can check the events too. A Handler is safe for concurrent use.
+type Handler struct {
+	mu         sync.Mutex
+	log        io.Writer
+	response   Response
+	script     []Response
+	requests   int
+	events     []trakerr.AppEvent
+	rejections []string
+}
+
+// NewHandler returns a Handler that accepts every valid event.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SetResponse sets how the requests that are not scripted with Enqueue are answered, eg. with a delay or always
+// with a 503. A successful status still rejects invalid events.
+func (handler *Handler) SetResponse(response Response) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.response = response
+}
+
+// SetLog writes a line to w for every posted event: whether it was accepted, rejected or failed as scripted.
+func (handler *Handler) SetLog(w io.Writer) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.log = w
+}
+
+// Enqueue scripts the answers of the next requests, one response per request in order.
+func (handler *Handler) Enqueue(responses ...Response) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.script = append(handler.script, responses...)
+}
+
+// Events returns the events accepted so far, oldest first.
+func (handler *Handler) Events() []trakerr.AppEvent {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return append([]trakerr.AppEvent(nil), handler.events...)
+}
+
+// Rejections returns why the invalid events posted so far were rejected.
+func (handler *Handler) Rejections() []string {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return append([]string(nil), handler.rejections...)
+}
+
+// Requests returns the number of events posted so far, including the rejected and failed ones.
+func (handler *Handler) Requests() int {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.requests
+}
+
+// Reset forgets the recorded events, rejections and requests and the scripted responses.
+func (handler *Handler) Reset() {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.script, handler.requests, handler.events, handler.rejections = nil, 0, nil, nil
+}
+
+func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/events" {
+		writeError(w, http.StatusNotFound, "no such endpoint "+r.URL.Path)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		handler.post(w, r)
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handler.Events())
+	case http.MethodDelete:
+		handler.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" is not allowed")
+	}
+}
+
+// post answers a posted event with the next response, and records it when the response is a success and the
+// event is valid.
+func (handler *Handler) post(w http.ResponseWriter, r *http.Request) {
+	handler.mu.Lock()
+	handler.requests++
+	response := handler.response
+	if len(handler.script) > 0 {
+		response, handler.script = handler.script[0], handler.script[1:]
+	}
+	handler.mu.Unlock()
+
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if response.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(response.RetryAfter/time.Second)))
+	}
+	if response.Status != 0 && (response.Status < 200 || response.Status > 299) {
+		handler.logf("failed: answered %d %s", response.Status, http.StatusText(response.Status))
+		writeError(w, response.Status, http.StatusText(response.Status))
+		return
+	}
+
+	appEvent, err := trakerr.DecodeAppEvent(r.Body)
+	if err == nil {
+		err = appEvent.Validate()
+	}
+	if err != nil {
+		handler.mu.Lock()
+		handler.rejections = append(handler.rejections, err.Error())
+		handler.mu.Unlock()
+		handler.logf("rejected: %v", err)
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	handler.mu.Lock()
+	handler.events = append(handler.events, *appEvent)
+	handler.mu.Unlock()
+	handler.logf("accepted: %s %s: %s", appEvent.LogLevel, appEvent.EventType, appEvent.EventMessage)
+
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte("{}"))
+}
+
+func (handler *Handler) logf(format string, args ...interface{}) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.log != nil {
+		fmt.Fprintf(handler.log, format+"\n", args...)
+	}
+}
+
+// writeError answers with the error model of the events API.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(trakerr.ModelError{Code: int32(status), Message: message})
+}
+
+// Server is a Handler served on a local port by an httptest.Server.
+type Server struct {
+	*Handler
+
+	// base URL of the fake API, to pass to TrakerrClient.SetBaseURL
+	URL string
+
+	server *httptest.Server
+}
+
+// NewServer starts a Server. Close it when the test is done.
+func NewServer() *Server {
+	handler := NewHandler()
+	server := httptest.NewServer(handler)
+	return &Server{Handler: handler, URL: server.URL, server: server}
+}
+
+// Close shuts the server down, blocking until the requests in flight are answered.
+func (server *Server) Close() {
+	server.server.Close()
+}
@@ -0,0 +1,124 @@
+package trakerrtest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trakerr-io/trakerr-go/src/trakerr"
+)
+
+func newClient(server *Server) *trakerr.TrakerrClient {
+	client := trakerr.NewTrakerrClient("test-api-key", "1.0", "test")
+	client.SetBaseURL(server.URL)
+	return client
+}
+
+func TestServerRecordsEvents(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := newClient(server)
+
+	if _, err := client.SendEvent(client.NewAppEvent("info", "", "Login", "user logged in")); err != nil {
+		t.Fatal(err)
+	}
+	client.SendError("error", "", errors.New("boom"))
+
+	events := server.Events()
+	if len(events) != 2 || server.Requests() != 2 || len(server.Rejections()) != 0 {
+		t.Fatalf("expected 2 events, got %d of %d requests, rejections %v", len(events), server.Requests(), server.Rejections())
+	}
+	if events[0].EventType != "Login" || events[0].ApiKey != "test-api-key" || events[1].EventMessage != "boom" || len(events[1].EventStacktrace) == 0 {
+		t.Errorf("unexpected events %+v", events)
+	}
+
+	response, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var listed []trakerr.AppEvent
+	if err := json.NewDecoder(response.Body).Decode(&listed); err != nil || len(listed) != 2 {
+		t.Errorf("GET /events returned %d events: %v", len(listed), err)
+	}
+
+	request, _ := http.NewRequest("DELETE", server.URL+"/events", nil)
+	if response, err := http.DefaultClient.Do(request); err != nil || response.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE /events failed: %v", err)
+	}
+	if len(server.Events()) != 0 || server.Requests() != 0 {
+		t.Error("DELETE /events didn't reset the server")
+	}
+}
+
+func TestServerRejectsInvalidEvents(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	for _, body := range []string{
+		`{"apiKey": "key", "classification": "issue", "eventType": "Crash", "logLevel": "loud"}`,
+		`{"apiKey": "key", "eventMesage": "typo"}`,
+		`not json`,
+	} {
+		response, err := http.Post(server.URL+"/events", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var modelError trakerr.ModelError
+		json.NewDecoder(response.Body).Decode(&modelError)
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest || modelError.Code != http.StatusBadRequest || modelError.Message == "" {
+			t.Errorf("%s: got %d %+v", body, response.StatusCode, modelError)
+		}
+	}
+	rejections := server.Rejections()
+	if len(rejections) != 3 || !strings.Contains(rejections[0], "eventMessage is required") || !strings.Contains(rejections[0], `logLevel "loud"`) {
+		t.Errorf("unexpected rejections %q", rejections)
+	}
+	if len(server.Events()) != 0 {
+		t.Error("an invalid event was recorded")
+	}
+}
+
+func TestServerScriptedFailures(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := newClient(server)
+	client.SetRateLimit(1000, 1000)
+
+	server.Enqueue(Response{Status: http.StatusTooManyRequests, RetryAfter: time.Hour})
+	if response, err := client.SendEvent(client.NewEmptyEvent()); err != nil || response.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429, got %v %v", response, err)
+	}
+	if _, err := client.SendEvent(client.NewEmptyEvent()); !errors.Is(err, trakerr.ErrRateLimited) {
+		t.Errorf("the client didn't back off after the 429: %v", err)
+	}
+	client.SetRateLimit(0, 0)
+
+	client.SetCircuitBreaker(trakerr.CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour})
+
+	server.Enqueue(Response{Status: http.StatusServiceUnavailable}, Response{Status: http.StatusInternalServerError})
+	client.SendEvent(client.NewEmptyEvent())
+	client.SendEvent(client.NewEmptyEvent())
+	if _, err := client.SendEvent(client.NewEmptyEvent()); !errors.Is(err, trakerr.ErrCircuitOpen) {
+		t.Errorf("the circuit didn't open after two failures: %v", err)
+	}
+	if server.Requests() != 3 || len(server.Events()) != 0 {
+		t.Errorf("expected 3 failed requests, got %d requests and %d events", server.Requests(), len(server.Events()))
+	}
+
+	server.SetResponse(Response{Delay: 50 * time.Millisecond})
+	start := time.Now()
+	if response, err := newClient(server).SendEvent(client.NewEmptyEvent()); err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected result %v %v", response, err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("the response was not delayed: %v", elapsed)
+	}
+	if len(server.Events()) != 1 {
+		t.Errorf("expected the delayed event, got %d events", len(server.Events()))
+	}
+}